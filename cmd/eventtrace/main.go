@@ -0,0 +1,157 @@
+// eventtrace filters and summarizes the event trace written by running the
+// game with -trace, e.g. every collision involving entity 3 between frames
+// 200 and 260:
+//
+//	eventtrace -type collision-enter,collision-exit -entity 3 -from 200 -to 260 trace.jsonl
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// entry mirrors engine.TraceEntry, the engine package needs sdl so it
+// isn't imported here
+type entry struct {
+	Frame    uint64          `json:"frame"`
+	Time     uint32          `json:"time"`
+	Type     string          `json:"type"`
+	Async    bool            `json:"async"`
+	Entities []int           `json:"entities"`
+	Event    json.RawMessage `json:"event"`
+}
+
+type filter struct {
+	types  map[string]bool
+	entity int
+	from   int64
+	to     int64
+}
+
+func (f *filter) matches(e *entry) bool {
+	if len(f.types) > 0 && !f.types[e.Type] {
+		return false
+	}
+	if f.from >= 0 && e.Frame < uint64(f.from) {
+		return false
+	}
+	if f.to >= 0 && e.Frame > uint64(f.to) {
+		return false
+	}
+	if f.entity >= 0 {
+		for _, index := range e.Entities {
+			if index == f.entity {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+type typeCount struct {
+	name  string
+	count int
+	first uint64
+	last  uint64
+}
+
+func main() {
+	types := flag.String("type", "", "comma separated event types to show, e.g. collision-enter,collection")
+	entity := flag.Int("entity", -1, "only show events involving this entity index")
+	from := flag.Int64("from", -1, "first frame to show")
+	to := flag.Int64("to", -1, "last frame to show")
+	summary := flag.Bool("summary", false, "print a count per event type instead of the events")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: eventtrace [flags] trace-file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f := &filter{
+		types:  make(map[string]bool),
+		entity: *entity,
+		from:   *from,
+		to:     *to,
+	}
+	for _, name := range strings.Split(*types, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			f.types[name] = true
+		}
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	counts := make(map[string]*typeCount)
+	scanner := bufio.NewScanner(file)
+	// events carrying component data can make for long lines
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s\n", flag.Arg(0), line, err)
+			continue
+		}
+		if !f.matches(&e) {
+			continue
+		}
+
+		if *summary {
+			c, ok := counts[e.Type]
+			if !ok {
+				c = &typeCount{name: e.Type, first: e.Frame}
+				counts[e.Type] = c
+			}
+			c.count++
+			c.last = e.Frame
+			continue
+		}
+		flags := ""
+		if e.Async {
+			flags += " (async)"
+		}
+		// payloads hold full entity ids, generation included, so list
+		// the plain indexes alongside
+		if len(e.Entities) > 0 {
+			flags += fmt.Sprintf(" entities=%v", e.Entities)
+		}
+		fmt.Printf("%6d %8dms  %s%s %s\n", e.Frame, e.Time, e.Type, flags, e.Event)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+
+	if *summary {
+		var sorted []*typeCount
+		for _, c := range counts {
+			sorted = append(sorted, c)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].count != sorted[j].count {
+				return sorted[i].count > sorted[j].count
+			}
+			return sorted[i].name < sorted[j].name
+		})
+		fmt.Printf("%-20s %8s %8s %8s\n", "type", "count", "first", "last")
+		for _, c := range sorted {
+			fmt.Printf("%-20s %8d %8d %8d\n", c.name, c.count, c.first, c.last)
+		}
+	}
+}
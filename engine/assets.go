@@ -69,6 +69,9 @@ func (ta *TextureAtlas) Get(asset string) []AnimationFrame {
 }
 
 func (ta *TextureAtlas) Cleanup() {
-	ta.Texture.Destroy()
+	// headless engines never load the texture
+	if ta.Texture != nil {
+		ta.Texture.Destroy()
+	}
 }
 
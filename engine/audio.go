@@ -6,13 +6,21 @@ import (
 	"os"
 )
 
-type AudioManager struct {
+type AudioManager interface {
+	Init()
+	PlayBgMusic(asset string)
+	PlaySoundEffect(name string)
+	Cleanup()
+}
+
+// SdlAudioManager plays audio through SDL_mixer
+type SdlAudioManager struct {
 	engine *Engine
 	Sounds map[string]*mix.Music
 	buffer int
 }
 
-func (mm *AudioManager) Init() {
+func (mm *SdlAudioManager) Init() {
 	// mm.buffer = 4096
 	mm.buffer = 2048
 	if err := mix.Init(mix.INIT_MP3); err != nil {
@@ -39,7 +47,7 @@ func (mm *AudioManager) Init() {
 	}
 }
 
-func (mm *AudioManager) PlayBgMusic(asset string) {
+func (mm *SdlAudioManager) PlayBgMusic(asset string) {
 	path := mm.engine.File.GetAudioPath(asset)
 	music := mm.Sounds[path]
 	if music != nil {
@@ -52,7 +60,7 @@ func (mm *AudioManager) PlayBgMusic(asset string) {
 	}
 }
 
-func (am *AudioManager) PlaySoundEffect(name string) {
+func (am *SdlAudioManager) PlaySoundEffect(name string) {
 	path := am.engine.File.GetAudioPath(name)
 	if sound, err := mix.LoadWAV(path); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load sound effect: %s\n", err)
@@ -61,7 +69,7 @@ func (am *AudioManager) PlaySoundEffect(name string) {
 	}
 }
 
-func (mm *AudioManager) Cleanup() {
+func (mm *SdlAudioManager) Cleanup() {
 	for _, val := range mm.Sounds {
 		val.Free()
 	}
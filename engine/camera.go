@@ -11,8 +11,9 @@ type Camera struct {
 	x float32
 	y float32
 
-	targetX *float32
-	targetY *float32
+	targetX float32
+	targetY float32
+	following bool
 
 	targetMode int
 
@@ -25,23 +26,23 @@ func (c *Camera) Move(moveX, moveY float32) {
 }
 
 func (c *Camera) X() float32 {
-	if c.targetX != nil {
+	if c.following {
 		if c.targetMode == TARGET_MODE_CENTER {
 			ww := float32(c.engine.Config.WindowWidth)
-			return *c.targetX - (ww / (2 * (c.engine.Config.Scale)))
+			return c.targetX - (ww / (2 * (c.engine.Config.Scale)))
 		}
-		return *c.targetX
+		return c.targetX
 	}
 	return c.x
 }
 
 func (c *Camera) Y() float32 {
-	if c.targetY != nil {
+	if c.following {
 		if c.targetMode == TARGET_MODE_CENTER {
 			wh := float32(c.engine.Config.WindowHeight)
-			return *c.targetY - (wh / (2 * c.engine.Config.Scale))
+			return c.targetY - (wh / (2 * c.engine.Config.Scale))
 		}
-		return *c.targetY
+		return c.targetY
 	}
 	return c.y
 }
@@ -49,11 +50,15 @@ func (c *Camera) Y() float32 {
 func (c *Camera) SetPos(x, y float32) {
 	c.x = x
 	c.y = y
+	c.following = false
 }
 
-func (c *Camera) SetTarget(x, y *float32) {
+// Follow points the camera at a target position, it keeps following
+// until SetPos is called
+func (c *Camera) Follow(x, y float32) {
 	c.targetX = x
 	c.targetY = y
+	c.following = true
 }
 
 func (c *Camera) Update(keysHeld map[sdl.Keycode]bool) {
@@ -0,0 +1,73 @@
+package engine
+
+// CommandBuffer queues structural changes to the World so systems don't
+// create or destroy entities while other systems may still be holding
+// their IDs.  Queued commands are applied in order when the buffer is
+// flushed, which the World does between every system update.
+type CommandBuffer struct {
+	commands []func(world *World)
+}
+
+func NewCommandBuffer() *CommandBuffer {
+	return &CommandBuffer{}
+}
+
+// CreateEntity queues the creation of a new entity.  build is called with
+// the new entity's handle when the buffer is flushed.
+func (cb *CommandBuffer) CreateEntity(build func(world *World, entity Entity)) {
+	cb.commands = append(cb.commands, func(world *World) {
+		entity := world.CreateEntity()
+		if build != nil {
+			build(world, entity)
+		}
+	})
+}
+
+// DestroyEntity queues the entity for destruction.  Destroying the same
+// entity more than once in a frame is harmless.
+func (cb *CommandBuffer) DestroyEntity(entity Entity) {
+	cb.commands = append(cb.commands, func(world *World) {
+		if world.Alive(entity) {
+			world.DestroyEntity(entity)
+		}
+	})
+}
+
+func (cb *CommandBuffer) AddComponents(entity Entity, signature uint64) {
+	cb.commands = append(cb.commands, func(world *World) {
+		if world.Alive(entity) {
+			world.AddComponents(entity, signature)
+		}
+	})
+}
+
+func (cb *CommandBuffer) RemoveComponents(entity Entity, signature uint64) {
+	cb.commands = append(cb.commands, func(world *World) {
+		if world.Alive(entity) {
+			world.RemoveComponents(entity, signature)
+		}
+	})
+}
+
+// Flush applies every queued command.  Commands queued while flushing
+// (e.g. from a create callback) are applied in the same flush.
+func (cb *CommandBuffer) Flush(world *World) {
+	for i := 0; i < len(cb.commands); i++ {
+		cb.commands[i](world)
+	}
+	cb.commands = cb.commands[:0]
+}
+
+// AddDeferred queues adding the component with the given value
+func (c *ComponentType[T]) AddDeferred(cb *CommandBuffer, entity Entity, value T) {
+	cb.commands = append(cb.commands, func(world *World) {
+		c.Add(world, entity, value)
+	})
+}
+
+// RemoveDeferred queues removing the component
+func (c *ComponentType[T]) RemoveDeferred(cb *CommandBuffer, entity Entity) {
+	cb.commands = append(cb.commands, func(world *World) {
+		c.Remove(world, entity)
+	})
+}
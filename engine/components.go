@@ -1,6 +1,11 @@
 package engine
 
-import "github.com/veandco/go-sdl2/sdl"
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"strconv"
+)
 
 const (
 	COMPONENT_NONE = 0
@@ -18,6 +23,11 @@ const (
 	COMPONENT_COLLECTIBLE = 1 << 12
 	COMPONENT_TEXT = 1 << 13
 	COMPONENT_HUD = 1 << 14
+	COMPONENT_PARENT = 1 << 15
+	COMPONENT_PLATFORM = 1 << 16
+	COMPONENT_LADDER = 1 << 17
+	COMPONENT_WATER = 1 << 18
+	COMPONENT_PHYSICS_BODY = 1 << 19
 )
 
 const (
@@ -47,26 +57,97 @@ const (
 	ENTITY_STATE_ROLL
 	ENTITY_STATE_WALLR
 	ENTITY_STATE_WALLL
+	ENTITY_STATE_CLIMB
 )
 
+// names used for states in prefab and snapshot files
+var stateKeyNames = map[StateKey]string{
+	ENTITY_STATE_IDLE:  "idle",
+	ENTITY_STATE_LEFT:  "left",
+	ENTITY_STATE_RIGHT: "right",
+	ENTITY_STATE_JUMP:  "jump",
+	ENTITY_STATE_SHOOT: "shoot",
+	ENTITY_STATE_DIE:   "die",
+	ENTITY_STATE_ROLL:  "roll",
+	ENTITY_STATE_WALLR: "wallr",
+	ENTITY_STATE_WALLL: "walll",
+	ENTITY_STATE_CLIMB: "climb",
+}
+
+func (k StateKey) MarshalText() ([]byte, error) {
+	if name, ok := stateKeyNames[k]; ok {
+		return []byte(name), nil
+	}
+	return []byte(strconv.Itoa(int(k))), nil
+}
+
+// UnmarshalText accepts a state name or, for states without one, a number
+func (k *StateKey) UnmarshalText(text []byte) error {
+	for key, name := range stateKeyNames {
+		if name == string(text) {
+			*k = key
+			return nil
+		}
+	}
+	n, err := strconv.Atoi(string(text))
+	if err != nil {
+		return fmt.Errorf("unknown entity state: %s", text)
+	}
+	*k = StateKey(n)
+	return nil
+}
+
+// UnmarshalJSON also takes a bare number, which is how states were
+// written before they had names
+func (k *StateKey) UnmarshalJSON(data []byte) error {
+	if n, err := strconv.Atoi(string(data)); err == nil {
+		*k = StateKey(n)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	return k.UnmarshalText([]byte(text))
+}
+
 type Transform struct {
 	X float32
 	Y float32
 	W int32
 	H int32
 
+	// position at the start of the current tick, only tracked
+	// for entities with COMPONENT_VELOCITY
+	PrevX float32
+	PrevY float32
+
 	SpeedX    float32
 	SpeedY    float32
 	AccelX    float32
 	AccelY    float32
-	MaxSpeedX float32
-	MaxSpeedY float32
+
+	// how far the platform the entity is standing on moved this tick,
+	// added to the entity's own move and cleared by the movement system
+	CarryX float32
+	CarryY float32
 
 	Sensor struct {
 		Top bool
 		Bottom bool
 		Left bool
 		Right bool
+		// Bottom is set because the entity is standing on a one-way tile
+		// or platform entity
+		OneWay bool
+		// or on a slope
+		Slope bool
+		// platform entity being stood on, ENTITY_NONE on the map
+		Platform Entity
+		// ladder entity under the middle of the entity's feet
+		Ladder Entity
+		// water entity the middle of the entity is in
+		Water Entity
 	}
 }
 
@@ -102,11 +183,17 @@ type State struct {
 	Grounded    bool
 	MoveRight   bool
 	MoveLeft    bool
+	MoveUp      bool
+	MoveDown    bool
 	Rolling     bool
 	Shooting    bool
 	LeftSlide   bool
 	RightSlide  bool
 	Sliding     bool
+	// falling through the one-way tile the entity was standing on
+	Dropping    bool
+	// on a ladder, gravity is off and up and down move the entity
+	Climbing    bool
 	JumpCount   int
 	JumpFrameCount int
 	Orientation Orientation
@@ -117,6 +204,10 @@ type State struct {
 
 type Animation struct {
 	AnimationStates map[StateKey]AnimationState
+	// used in place of AnimationStates while the entity is under water,
+	// states missing from it fall back to AnimationStates
+	UnderwaterStates map[StateKey]AnimationState
+	Underwater      bool
 	AnimState       StateKey
 	CurrentFrame    int
 	FrameInc        int
@@ -127,11 +218,18 @@ type Animation struct {
 }
 
 func (a *Animation) CurrentState() AnimationState {
+	if state, ok := a.UnderwaterStates[a.AnimState]; ok && a.Underwater {
+		return state
+	}
 	return a.AnimationStates[a.AnimState]
 }
 
+// Tag holds an entity's tags and the named groups it belongs to.  Use
+// World.AddTag, AddToGroup, etc to change them so the world's tag index
+// stays current.
 type Tag struct {
-	Value string
+	Values []string
+	Groups []string
 }
 
 type Inventory struct {
@@ -147,9 +245,130 @@ type Text struct {
 	Value string
 }
 
+// Parent links an entity to the entity it is attached to.  The child's
+// Transform X and Y are relative to the parent's world position.
+type Parent struct {
+	Entity Entity
+}
+
+// Platform lets other entities stand on the entity.  Like one-way tiles
+// they can only be landed on from above.  With a path it moves back and
+// forth along it, carrying whatever is standing on top.
+type Platform struct {
+	// points the middle of the platform passes through, in map pixels
+	Path []PathPoint
+	// pixels per tick, the same as Transform speeds
+	Speed float32
+	// linear, ease-in, ease-out or ease-in-out, applied to each leg of
+	// the path so the platform slows into every point
+	Easing string
+	// carry on from the last point back to the first instead of reversing
+	Loop bool
+	// entity builder placed at each point of the path, e.g. "wheel"
+	Marker string
+
+	// the leg of the path the platform is on and how far along it, 0 to 1
+	Leg      int
+	Progress float32
+	Reverse  bool
+}
+
+// PhysicsBody holds how an entity moves under PhysicsSystem.  Entities
+// without one move like DefaultPhysicsBody, and fields left out of a
+// prefab or snapshot take its values.
+type PhysicsBody struct {
+	// multiplies GRAVITY
+	GravityScale float32
+	// horizontal acceleration running and rolling
+	Accel     float32
+	RollAccel float32
+	// deceleration when not running
+	Friction float32
+	// vertical speed a jump starts with, negative is up
+	JumpSpeed float32
+	// multiplies JumpSpeed for the second jump in the air
+	DoubleJumpScale float32
+	// horizontal speed away from the wall a wall jump starts with
+	WallJumpSpeed float32
+	MaxSpeedX     float32
+	MaxSpeedY     float32
+	// added to the max speeds while rolling
+	RollSpeed  float32
+	ClimbSpeed float32
+}
+
+// pixels per tick per tick
+const GRAVITY = .13
+
+var DefaultPhysicsBody = PhysicsBody{
+	GravityScale:    1,
+	Accel:           0.2,
+	RollAccel:       2,
+	Friction:        .3,
+	JumpSpeed:       -5,
+	DoubleJumpScale: 1,
+	WallJumpSpeed:   5,
+	MaxSpeedX:       2.2,
+	MaxSpeedY:       4,
+	RollSpeed:       2,
+	ClimbSpeed:      1.2,
+}
+
+func (b *PhysicsBody) UnmarshalJSON(data []byte) error {
+	// a plain copy of the type so this method isn't called again
+	type plain PhysicsBody
+	body := plain(DefaultPhysicsBody)
+	if err := decodeStrict(data, &body); err != nil {
+		return err
+	}
+	*b = PhysicsBody(body)
+	return nil
+}
+
+// Water is a region that changes how entities move while their middle is
+// inside it.  The scales multiply gravity, the maximum speeds and the
+// speed of a swimming stroke, jumping under water always gives a stroke.
+type Water struct {
+	Gravity  float32
+	MaxSpeed float32
+	Jump     float32
+	// sound played entering and leaving the water
+	Splash string
+}
+
+type PathPoint struct {
+	X float32
+	Y float32
+}
+
 // empty components for tagging
+type Velocity struct {}
 type Controller struct {}
 type Focused struct {}
 type Collidable struct {}
 type Hud struct {}
+// stacks of ladder entities form a ladder, see ladders.go
+type Ladder struct {}
+
+// built-in components go through the same registry as game defined ones,
+// they just keep the mask bits they've always had
+var (
+	VelocityComponent    = registerComponent[Velocity]("velocity", COMPONENT_VELOCITY)
+	AnimationComponent   = registerComponent[Animation]("animation", COMPONENT_ANIMATION)
+	FocusedComponent     = registerComponent[Focused]("focused", COMPONENT_FOCUSED)
+	StateComponent       = registerComponent[State]("state", COMPONENT_STATE)
+	ControllerComponent  = registerComponent[Controller]("controller", COMPONENT_CONTROLLER)
+	TransformComponent   = registerComponent[Transform]("transform", COMPONENT_TRANSFORM)
+	CollidableComponent  = registerComponent[Collidable]("collidable", COMPONENT_COLLIDABLE)
+	TagComponent         = registerComponent[Tag]("tag", COMPONENT_TAG)
+	InventoryComponent   = registerComponent[Inventory]("inventory", COMPONENT_INVENTORY)
+	CollectibleComponent = registerComponent[Collectible]("collectible", COMPONENT_COLLECTIBLE)
+	TextComponent        = registerComponent[Text]("text", COMPONENT_TEXT)
+	HudComponent         = registerComponent[Hud]("hud", COMPONENT_HUD)
+	ParentComponent      = registerComponent[Parent]("parent", COMPONENT_PARENT)
+	PlatformComponent    = registerComponent[Platform]("platform", COMPONENT_PLATFORM)
+	LadderComponent      = registerComponent[Ladder]("ladder", COMPONENT_LADDER)
+	WaterComponent       = registerComponent[Water]("water", COMPONENT_WATER)
+	PhysicsBodyComponent = registerComponent[PhysicsBody]("physics", COMPONENT_PHYSICS_BODY)
+)
 
@@ -1,41 +1,222 @@
 package engine
 
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// Subscriber queues events for later, systems embed SystemEvents to get one
 type Subscriber interface {
 	PostEvent(Event)
 }
 
+// Dispatcher delivers events to the handlers subscribed to their type.
+// Handlers run in priority order, highest first, and in subscription order
+// within a priority.
 type Dispatcher struct {
-	subscribers map[string][]Subscriber
+	handlers map[reflect.Type][]*Subscription
+	// subscriptions to interface types, checked against every event
+	interfaces []reflect.Type
+	// events emitted by a handler, delivered after the current event
+	pending     []Event
+	dispatching bool
+	// async events waiting for Flush
+	queued []Event
+	nextId int
+	// records every emitted event when set
+	trace *EventTrace
 }
 
 type Subscription struct {
-	Unsubscribe func()
+	dispatcher *Dispatcher
+	eventType  reflect.Type
+	call       func(Event)
+	priority   int
+	once       bool
+	id         int
+	active     bool
+}
+
+type SubscribeOption func(*Subscription)
+
+// Priority sets the order a handler runs in, higher runs first.  The
+// default is 0.
+func Priority(priority int) SubscribeOption {
+	return func(s *Subscription) { s.priority = priority }
+}
+
+// Once unsubscribes the handler after the first event it receives
+func Once() SubscribeOption {
+	return func(s *Subscription) { s.once = true }
 }
 
 func NewDispatcher() *Dispatcher {
 	return &Dispatcher{
-		subscribers: make(map[string][]Subscriber),
+		handlers: make(map[reflect.Type][]*Subscription),
 	}
 }
 
-func (d *Dispatcher) Subscribe(eventType string, sub Subscriber) Subscription {
-	var idx int
+// On calls handler with every emitted event of type T.  T is normally a
+// concrete event pointer such as *CollisionEnterEvent; an interface type
+// receives every event that implements it.
+func On[T Event](d *Dispatcher, handler func(T), opts ...SubscribeOption) *Subscription {
+	return d.subscribe(eventType[T](), func(e Event) { handler(e.(T)) }, opts)
+}
+
+// Queue posts every emitted event of type T to sub, systems use it to
+// collect events and handle them in their next Update
+func Queue[T Event](d *Dispatcher, sub Subscriber, opts ...SubscribeOption) *Subscription {
+	return d.subscribe(eventType[T](), sub.PostEvent, opts)
+}
+
+func eventType[T Event]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (d *Dispatcher) subscribe(t reflect.Type, call func(Event), opts []SubscribeOption) *Subscription {
+	s := &Subscription{
+		dispatcher: d,
+		eventType:  t,
+		call:       call,
+		id:         d.nextId,
+		active:     true,
+	}
+	d.nextId++
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if t.Kind() == reflect.Interface {
+		if _, ok := d.handlers[t]; !ok {
+			d.interfaces = append(d.interfaces, t)
+		}
+	}
+	// lists are replaced rather than edited so an emit that is ranging
+	// over the old one isn't affected
+	list := d.handlers[t]
+	pos := sort.Search(len(list), func(i int) bool { return list[i].priority < s.priority })
+	updated := make([]*Subscription, 0, len(list)+1)
+	updated = append(updated, list[:pos]...)
+	updated = append(updated, s)
+	d.handlers[t] = append(updated, list[pos:]...)
+	return s
+}
 
-	d.subscribers[eventType] = append(d.subscribers[eventType], sub)
-	idx = len(d.subscribers) - 1
+// Unsubscribe stops the handler receiving events.  It is safe to call more
+// than once and from inside a handler.
+func (s *Subscription) Unsubscribe() {
+	if s == nil || !s.active {
+		return
+	}
+	s.active = false
 
-	return Subscription {
-		Unsubscribe: func() {
-			d.subscribers[eventType] = append(
-				d.subscribers[eventType][:idx],
-				d.subscribers[eventType][idx+1:]...
-			)
-		},
+	d := s.dispatcher
+	list := d.handlers[s.eventType]
+	for i, other := range list {
+		if other == s {
+			updated := make([]*Subscription, 0, len(list)-1)
+			updated = append(updated, list[:i]...)
+			d.handlers[s.eventType] = append(updated, list[i+1:]...)
+			return
+		}
 	}
 }
 
+func (s *Subscription) Active() bool {
+	return s != nil && s.active
+}
+
+// EmitEvent delivers a synchronous event to its handlers straight away.
+// Async events are held until the next Flush.  Events emitted from inside
+// a handler are queued and delivered in order once the current event has
+// reached all of its handlers.
 func (d *Dispatcher) EmitEvent(e Event) {
-	for _, sub := range d.subscribers[e.Type()] {
-		sub.PostEvent(e)
+	if d.trace != nil {
+		if err := d.trace.Record(e); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to trace %s event: %s\n", e.Type(), err)
+		}
+	}
+	if e.Async() {
+		d.queued = append(d.queued, e)
+		return
+	}
+	d.dispatch(e)
+}
+
+// SetTrace records every event emitted from now on to trace, nil stops
+// tracing
+func (d *Dispatcher) SetTrace(trace *EventTrace) {
+	d.trace = trace
+}
+
+// Flush delivers the queued async events in the order they were emitted.
+// Async events emitted by their handlers are delivered in the same flush,
+// so a whole chain of events settles before it returns.
+func (d *Dispatcher) Flush() {
+	for len(d.queued) > 0 {
+		next := d.queued[0]
+		d.queued = d.queued[1:]
+		d.dispatch(next)
+	}
+	d.queued = nil
+}
+
+func (d *Dispatcher) dispatch(e Event) {
+	d.pending = append(d.pending, e)
+	if d.dispatching {
+		return
+	}
+
+	d.dispatching = true
+	defer func() {
+		// a panicking handler drops whatever was still queued
+		d.dispatching = false
+		d.pending = nil
+	}()
+	for len(d.pending) > 0 {
+		next := d.pending[0]
+		d.pending = d.pending[1:]
+		d.deliver(next)
+	}
+}
+
+func (d *Dispatcher) deliver(e Event) {
+	for _, s := range d.subscribersFor(e) {
+		// an earlier handler may have unsubscribed this one
+		if !s.active {
+			continue
+		}
+		if s.once {
+			s.Unsubscribe()
+		}
+		s.call(e)
+	}
+}
+
+func (d *Dispatcher) subscribersFor(e Event) []*Subscription {
+	t := reflect.TypeOf(e)
+	list := d.handlers[t]
+	if len(d.interfaces) == 0 {
+		return list
+	}
+
+	var merged []*Subscription
+	for _, iface := range d.interfaces {
+		if iface != t && t.Implements(iface) {
+			merged = append(merged, d.handlers[iface]...)
+		}
+	}
+	if merged == nil {
+		return list
 	}
+	merged = append(merged, list...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].priority != merged[j].priority {
+			return merged[i].priority > merged[j].priority
+		}
+		return merged[i].id < merged[j].id
+	})
+	return merged
 }
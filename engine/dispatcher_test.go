@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testEvent struct {
+	Name string
+}
+
+func (te *testEvent) Type() string { return "test" }
+func (te *testEvent) Async() bool  { return false }
+
+type otherTestEvent struct{}
+
+func (oe *otherTestEvent) Type() string { return "other-test" }
+func (oe *otherTestEvent) Async() bool  { return false }
+
+func TestDispatcherPriority(t *testing.T) {
+	d := NewDispatcher()
+	var order []string
+	record := func(name string) func(*testEvent) {
+		return func(*testEvent) { order = append(order, name) }
+	}
+	On(d, record("default first"))
+	On(d, record("low"), Priority(-1))
+	On(d, record("high"), Priority(10))
+	On(d, record("default second"))
+
+	d.EmitEvent(&testEvent{})
+	want := []string{"high", "default first", "default second", "low"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+func TestDispatcherUnsubscribeInsideHandler(t *testing.T) {
+	d := NewDispatcher()
+	var calls []string
+	var second *Subscription
+	first := On(d, func(*testEvent) {
+		calls = append(calls, "first")
+		// removes itself and the handler after it
+		second.Unsubscribe()
+	})
+	second = On(d, func(*testEvent) { calls = append(calls, "second") })
+	On(d, func(*testEvent) { calls = append(calls, "third") })
+	var self *Subscription
+	self = On(d, func(*testEvent) {
+		calls = append(calls, "self")
+		self.Unsubscribe()
+	})
+
+	d.EmitEvent(&testEvent{})
+	first.Unsubscribe()
+	d.EmitEvent(&testEvent{})
+
+	want := []string{"first", "third", "self", "third"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("got %v, want %v", calls, want)
+	}
+	if first.Active() || second.Active() || self.Active() {
+		t.Error("unsubscribed handlers still active")
+	}
+}
+
+func TestDispatcherOnce(t *testing.T) {
+	d := NewDispatcher()
+	calls := 0
+	sub := On(d, func(*testEvent) { calls++ }, Once())
+	d.EmitEvent(&testEvent{})
+	d.EmitEvent(&testEvent{})
+	if calls != 1 || sub.Active() {
+		t.Fatalf("once handler called %d times, active %v", calls, sub.Active())
+	}
+}
+
+func TestDispatcherNestedEmitDeliveredAfter(t *testing.T) {
+	d := NewDispatcher()
+	var order []string
+	On(d, func(e *testEvent) {
+		order = append(order, "a:"+e.Name)
+		if e.Name == "outer" {
+			d.EmitEvent(&testEvent{Name: "inner"})
+			d.EmitEvent(&otherTestEvent{})
+		}
+	})
+	On(d, func(e *testEvent) { order = append(order, "b:"+e.Name) })
+	On(d, func(*otherTestEvent) { order = append(order, "other") })
+
+	d.EmitEvent(&testEvent{Name: "outer"})
+	want := []string{"a:outer", "b:outer", "a:inner", "b:inner", "other"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+// unsubscribing one handler used to remove another of the same type,
+// which cost the audio system one of its subscriptions
+func TestAudioSystemKeepsSubscriptions(t *testing.T) {
+	w := NewWorld()
+	other := On(w.Events, func(*CollectionEvent) {})
+	audio := &AudioSystem{}
+	if err := w.RegisterSystem(audio); err != nil {
+		t.Fatal(err)
+	}
+	later := On(w.Events, func(*AudioEvent) {})
+	other.Unsubscribe()
+	later.Unsubscribe()
+
+	w.Events.EmitEvent(&CollectionEvent{Collectible: "gold"})
+	w.Events.EmitEvent(&AudioEvent{Clip: "jump.wav"})
+	w.Events.Flush()
+
+	manager := &NullAudioManager{}
+	audio.Update(&Engine{Audio: manager}, w)
+	want := []string{"coin.wav", "jump.wav"}
+	if !reflect.DeepEqual(manager.Played, want) {
+		t.Fatalf("played %v, want %v", manager.Played, want)
+	}
+	for _, sub := range audio.subs {
+		if !sub.Active() {
+			t.Fatal("audio system lost a subscription")
+		}
+	}
+}
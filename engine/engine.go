@@ -1,28 +1,54 @@
 package engine
 
 import (
-	"github.com/veandco/go-sdl2/sdl"
 	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"math/rand"
 	"os"
+	"time"
 )
 
+// longest frame the simulation will try to catch up on, anything
+// beyond this (window drags, breakpoints) is dropped
+const MAX_FRAME_TIME = 250
+
+// simulation ticks per second.  Speeds, accelerations, friction and
+// frame counts are all tuned per tick so this is fixed, changing it
+// would change how the game plays.
+const TICK_RATE = 60
+
 type Engine struct {
 	running bool
 	paused bool
+	// simulation ticks run so far, the game clock
+	ticks uint64
+	// how far between the previous and current tick rendering is
+	alpha float32
+	// input being recorded to Config.RecordReplay
+	recording *Replay
+	// input being played back from Config.PlayReplay
+	playback *ReplayPlayer
+	// events being written to Config.TraceEvents
+	trace *EventTrace
 	World *World
-	renderer *sdl.Renderer
-	window *sdl.Window
 
-	Audio *AudioManager
+	// seeded from Config.Seed and recorded in replays.  Nothing in the
+	// engine is random, game code that is must draw from this rather
+	// than math/rand's global source or a replay won't play back the
+	// same run.
+	Rand *rand.Rand
+
+	Audio AudioManager
 	File *FileManager
-	Graphics *Graphics
+	Graphics Graphics
 	Input *InputManager
+	InputSource InputSource
 	Assets *TextureAtlas
 	FPS *Fps
 	Map *Map
 	Camera *Camera
 	Events *Dispatcher
-	Text *TextManager
+	Text TextManager
 	Config EngineConfig
 }
 
@@ -32,55 +58,103 @@ type EngineConfig struct {
 	WindowTitle string
 	Scale float32
 	DrawDebug bool
+	// run without a window, renderer or audio device.  Graphics, text,
+	// audio and input are replaced with no-op implementations so the
+	// world can be stepped from tests.
+	Headless bool
+	// directory assets, maps, etc are loaded from, defaults to the
+	// working directory
+	RootDir string
+	// seed for Engine.Rand, picked from the clock if zero
+	Seed int64
+	// file to record every tick's input to, written on Cleanup
+	RecordReplay string
+	// file to play input back from instead of the keyboard.  The
+	// replay's seed overrides Seed.
+	PlayReplay string
+	// file to log every event emitted by the world to, see
+	// cmd/eventtrace for reading it back
+	TraceEvents string
 }
 
-func New(cfg EngineConfig) *Engine {
+// New creates an engine and initializes its window, renderer, audio and
+// text, or their no-op versions when headless.  It returns an error if
+// any of them fail to start.
+func New(cfg EngineConfig) (*Engine, error) {
 	eng := &Engine{}
 
 	eng.FPS = 		&Fps{}
-	eng.File = 		&FileManager{}
+	eng.File = 		&FileManager{root: cfg.RootDir}
 	eng.Input = 	NewInputManager()
 	eng.Assets = 	&TextureAtlas{engine: eng}
-	eng.Text =      &TextManager{engine: eng}
-	eng.Graphics = 	&Graphics{engine: eng}
 	eng.Map = 		&Map{engine: eng}
-	eng.Audio = 	&AudioManager{engine: eng}
 	eng.Camera = 	&Camera{engine: eng}
 	eng.Camera.targetMode = TARGET_MODE_CENTER
 	eng.Config =	cfg
 
-	eng.Init()
-	return eng
-}
+	if cfg.Headless {
+		eng.Graphics =    &NullGraphics{}
+		eng.Text =        &NullTextManager{}
+		eng.Audio =       &NullAudioManager{}
+		eng.InputSource = &NullInputSource{}
+	} else {
+		graphics := &SdlGraphics{engine: eng}
+		eng.Graphics =    graphics
+		eng.Text =        &SdlTextManager{engine: eng, graphics: graphics}
+		eng.Audio =       &SdlAudioManager{engine: eng}
+		eng.InputSource = &SdlInputSource{}
+	}
 
-func (e *Engine) Init() error {
-	var err error
-	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
-		panic(err)
-		return err
+	if cfg.PlayReplay != "" {
+		if replay, err := LoadReplay(cfg.PlayReplay); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load replay: %s\n", err)
+		} else {
+			eng.Config.Seed = replay.Seed
+			eng.playback = NewReplayPlayer(replay)
+		}
 	}
 
-	e.window, err = sdl.CreateWindow(
-		e.Config.WindowTitle,
-		sdl.WINDOWPOS_UNDEFINED,
-		sdl.WINDOWPOS_UNDEFINED,
-		e.Config.WindowWidth,
-		e.Config.WindowHeight,
-		sdl.WINDOW_SHOWN,
-	)
+	if eng.Config.Seed == 0 {
+		eng.Config.Seed = time.Now().UnixNano()
+	}
+	eng.Rand = rand.New(rand.NewSource(eng.Config.Seed))
 
-	if err != nil {
-		panic(err)
-		return err
+	if cfg.RecordReplay != "" {
+		eng.recording = &Replay{
+			Version: REPLAY_VERSION,
+			Seed: eng.Config.Seed,
+			TickRate: TICK_RATE,
+		}
 	}
 
-	e.renderer, err = sdl.CreateRenderer(e.window, -1, sdl.RENDERER_ACCELERATED|sdl.RENDERER_PRESENTVSYNC)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create rederer: %s\n", err)
-		return err
+	if cfg.TraceEvents != "" {
+		clock := func() (uint64, uint32) { return eng.ticks, eng.GameTime() }
+		if trace, err := NewEventTrace(cfg.TraceEvents, clock); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create event trace: %s\n", err)
+		} else {
+			eng.trace = trace
+		}
 	}
 
-	e.renderer.SetScale(e.Config.Scale, e.Config.Scale)
+	if err := eng.Init(); err != nil {
+		if eng.trace != nil {
+			eng.trace.Close()
+		}
+		return nil, err
+	}
+	return eng, nil
+}
+
+func (e *Engine) Init() error {
+	if !e.Config.Headless {
+		if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+			return err
+		}
+	}
+
+	if err := e.Graphics.Init(); err != nil {
+		return err
+	}
 
 	e.Assets.Init()
 	e.Audio.Init()
@@ -89,58 +163,150 @@ func (e *Engine) Init() error {
 	return nil
 }
 
+// Run drives the simulation at a fixed tick rate regardless of the
+// display's refresh rate.  Real time is accumulated each frame and spent
+// in whole ticks, the remainder is used to interpolate rendering between
+// the last two ticks.
 func (g *Engine) Run() int {
+	g.attachTrace()
 	g.running = true
 	g.paused = false
+
+	tickMs := 1000 / float64(TICK_RATE)
+	accumulator := 0.0
+	previous := sdl.GetTicks()
 	for g.running {
-			g.HandleEvents()
-		if !g.paused {
-			g.Update()
+		now := sdl.GetTicks()
+		frameTime := now - previous
+		previous = now
+		if frameTime > MAX_FRAME_TIME {
+			frameTime = MAX_FRAME_TIME
 		}
+		accumulator += float64(frameTime)
+
+		g.HandleEvents()
+		for accumulator >= tickMs {
+			g.Tick()
+			accumulator -= tickMs
+		}
+		g.Render(float32(accumulator / tickMs))
 	}
 	g.Cleanup()
 	return 0
 }
 
 func (g *Engine) HandleEvents() {
+	g.InputSource.PollEvents(g)
+}
+
+// attachTrace starts tracing the world's events.  The world is set up
+// after New so this waits until the simulation starts.
+func (g *Engine) attachTrace() {
+	if g.trace != nil && g.World != nil {
+		g.World.Events.SetTrace(g.trace)
+	}
+}
+
+// Stop ends Run after the current frame
+func (g *Engine) Stop() {
+	g.running = false
+}
+
+func (g *Engine) Paused() bool {
+	return g.paused
+}
+
+// SetPaused stops the simulation while rendering carries on.  The input
+// phase keeps running so a system there can unpause, systems in it that
+// drive gameplay should check Paused.
+func (g *Engine) SetPaused(paused bool) {
+	g.paused = paused
+	g.World.SetPhaseEnabled(PHASE_SIMULATION, !paused)
+	g.World.SetPhaseEnabled(PHASE_POST_SIMULATION, !paused)
+}
+
+// Tick advances the simulation by one fixed step
+func (g *Engine) Tick() {
+	if g.playback != nil {
+		if g.playback.Done() {
+			g.Stop()
+			return
+		}
+		g.playback.apply(g)
+	}
+
 	/**
-	 * set keystates every frame so that lastState and currentState will be set correctly
+	 * set keystates every tick so that lastState and currentState will be set correctly
 	 */
 	g.Input.UpdateKeyStates()
-	for e := sdl.PollEvent(); e != nil; e = sdl.PollEvent() {
-		switch t := e.(type) {
-		case *sdl.QuitEvent:
-			g.running = false
-			break
-		case *sdl.KeyboardEvent:
-			g.Input.OnKeyboardEvent(e.(*sdl.KeyboardEvent))
-			sym := e.(*sdl.KeyboardEvent).Keysym.Sym
-			g.Input.KeysHeld[sym] = t.State == sdl.PRESSED
-			if sym == sdl.K_q {
-				g.running = false
-			}
-			if sym == sdl.K_p  && t.State == sdl.RELEASED {
-				fmt.Println("p pressed")
-				g.paused = !g.paused
-			}
-		}
+	if g.recording != nil {
+		g.recording.record(g)
+	}
+	g.World.StorePreviousTransforms()
+	// game time doesn't move while paused so neither do timers
+	g.World.Timers.Advance(g.GameTime())
+	g.World.UpdatePhases(g, PHASE_INPUT, PHASE_POST_SIMULATION)
+	g.World.FlushEvents()
+	if !g.paused {
+		g.ticks++
 	}
 }
 
-func (g *Engine) Update() {
+// Render draws the world alpha of the way from the previous tick to the current one
+func (g *Engine) Render(alpha float32) {
+	g.alpha = alpha
 	g.FPS.Update()
-	g.renderer.Clear()
-	g.World.Update(g)
-	g.renderer.Present()
+	g.Graphics.Clear()
+	g.World.UpdatePhases(g, PHASE_RENDER, PHASE_HUD)
+	g.Graphics.Present()
+}
+
+// Step polls input and runs n simulation ticks without rendering, for
+// driving a headless engine from tests or tools.  Render and hud phase
+// systems don't run, so they must not queue events for their Update.
+func (g *Engine) Step(n int) {
+	g.attachTrace()
+	for i := 0; i < n; i++ {
+		g.HandleEvents()
+		g.Tick()
+	}
+}
+
+func (g *Engine) Ticks() uint64 {
+	return g.ticks
+}
+
+// GameTime is the game clock in milliseconds.  It only advances while
+// the simulation is running so anything timed off it pauses with the game.
+func (g *Engine) GameTime() uint32 {
+	return uint32(g.ticks * 1000 / TICK_RATE)
+}
+
+// Interpolation is the alpha passed to the current Render
+func (g *Engine) Interpolation() float32 {
+	return g.alpha
 }
 
 func (g *Engine) Cleanup() {
+	if g.recording != nil {
+		if err := g.recording.Save(g.Config.RecordReplay); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save replay: %s\n", err)
+		}
+	}
+
+	if g.trace != nil {
+		if err := g.trace.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save event trace: %s\n", err)
+		}
+	}
+
 	g.Assets.Cleanup()
 	g.Audio.Cleanup()
 	g.Text.Cleanup()
+	g.Graphics.Cleanup()
 
-	g.renderer.Destroy()
-	g.window.Destroy()
-	sdl.Quit()
+	if !g.Config.Headless {
+		sdl.Quit()
+	}
 }
 
@@ -0,0 +1,24 @@
+package engine
+
+// Entity is a handle to a slot in the World.  The low 32 bits hold the
+// slot index and the high 32 bits hold the generation the slot was at
+// when the handle was created.  Destroying an entity bumps the slot's
+// generation, so any handle kept around afterwards no longer matches
+// and is reported as dead instead of pointing at whatever reused the slot.
+type Entity uint64
+
+// ENTITY_NONE is never handed out by the World (generations start at 1)
+// and can be used as a nil value for entity references.
+const ENTITY_NONE Entity = 0
+
+func NewEntity(index int, generation uint32) Entity {
+	return Entity(uint64(generation)<<32 | uint64(uint32(index)))
+}
+
+func (e Entity) Index() int {
+	return int(uint32(e))
+}
+
+func (e Entity) Generation() uint32 {
+	return uint32(e >> 32)
+}
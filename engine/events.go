@@ -1,16 +1,52 @@
 package engine
 
+// Event is anything sent through a Dispatcher.  Async events are held
+// until the end of frame flush, the rest are delivered as they're emitted.
 type Event interface {
 	Type() string
 	Async() bool
 }
 
-type CollisionEvent struct {
-	A int
-	B int
+// collision events are sent for pairs of collidable entities, A always
+// has the lower slot index.  Enter is sent on the first frame a pair
+// overlaps, Stay on every frame after that and Exit on the first frame
+// they don't, including when one of them has been destroyed.
+type CollisionEnterEvent struct {
+	A Entity
+	B Entity
 }
-func (ce *CollisionEvent) Type() string { return "collision" }
-func (ce *CollisionEvent) Async() bool { return true }
+func (ce *CollisionEnterEvent) Type() string { return "collision-enter" }
+func (ce *CollisionEnterEvent) Async() bool { return true }
+
+type CollisionStayEvent struct {
+	A Entity
+	B Entity
+}
+func (ce *CollisionStayEvent) Type() string { return "collision-stay" }
+func (ce *CollisionStayEvent) Async() bool { return true }
+
+type CollisionExitEvent struct {
+	A Entity
+	B Entity
+}
+func (ce *CollisionExitEvent) Type() string { return "collision-exit" }
+func (ce *CollisionExitEvent) Async() bool { return true }
+
+// water events are sent when the middle of a moving entity goes into or
+// comes out of a water region
+type WaterEnterEvent struct {
+	Entity Entity
+	Water  Entity
+}
+func (we *WaterEnterEvent) Type() string { return "water-enter" }
+func (we *WaterEnterEvent) Async() bool { return true }
+
+type WaterExitEvent struct {
+	Entity Entity
+	Water  Entity
+}
+func (we *WaterExitEvent) Type() string { return "water-exit" }
+func (we *WaterExitEvent) Async() bool { return true }
 
 type AudioEvent struct {
 	Clip string
@@ -31,17 +67,49 @@ type PhysicsPulseEvent struct {
 	SpeedY float32
 	AccelX float32
 	AccelY float32
-	Entity int
+	Entity Entity
 }
 func (ae *PhysicsPulseEvent) Type() string { return "physics-pulse" }
-func (ae *PhysicsPulseEvent) Async() bool { return true }
+func (ae *PhysicsPulseEvent) Async() bool { return false }
 
 type PhysicsSetEvent struct {
 	SpeedX float32
 	SpeedY float32
 	AccelX float32
 	AccelY float32
-	Entity int
+	Entity Entity
 }
 func (ae *PhysicsSetEvent) Type() string { return "physics-set" }
-func (ae *PhysicsSetEvent) Async() bool { return true }
+func (ae *PhysicsSetEvent) Async() bool { return false }
+
+type EntityCreatedEvent struct {
+	Entity Entity
+}
+func (ee *EntityCreatedEvent) Type() string { return "entity-created" }
+func (ee *EntityCreatedEvent) Async() bool { return false }
+
+// EntityDestroyedEvent is emitted after the entity is dead, Mask holds
+// the components it had
+type EntityDestroyedEvent struct {
+	Entity Entity
+	Mask uint64
+}
+func (ee *EntityDestroyedEvent) Type() string { return "entity-destroyed" }
+func (ee *EntityDestroyedEvent) Async() bool { return false }
+
+// ComponentAddedEvent is emitted once per component bit set on an entity
+type ComponentAddedEvent struct {
+	Entity Entity
+	Component uint64
+}
+func (ce *ComponentAddedEvent) Type() string { return "component-added" }
+func (ce *ComponentAddedEvent) Async() bool { return false }
+
+// ComponentRemovedEvent is emitted once per component bit cleared on an
+// entity, including when the entity is destroyed
+type ComponentRemovedEvent struct {
+	Entity Entity
+	Component uint64
+}
+func (ce *ComponentRemovedEvent) Type() string { return "component-removed" }
+func (ce *ComponentRemovedEvent) Async() bool { return false }
@@ -6,7 +6,10 @@ import (
 	"io/ioutil"
 )
 
-type FileManager struct {}
+type FileManager struct {
+	// base directory, the working directory if empty
+	root string
+}
 
 func (f *FileManager) GetMap(filename string) (*os.File, error) {
 	mapPath := f.GetPath("maps", filename, "map")
@@ -48,6 +51,10 @@ func (f *FileManager) GetDirectoryContents(dir string) ([]string, error) {
 }
 
 func (f *FileManager) GetDirectoryPath(dir string) string {
+	if f.root != "" {
+		return f.root + "/" + dir
+	}
+
 	path, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to get working directory: %s", err)
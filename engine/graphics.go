@@ -7,12 +7,51 @@ import (
 	"os"
 )
 
-type Graphics struct {
+type Graphics interface {
+	Init() error
+	Load(file string) (*sdl.Texture, error)
+	DrawFull(texture *sdl.Texture, x int32, y int32)
+	DrawPart(texture *sdl.Texture, x int32, y int32, clipX int32, clipY int32, w int32, h int32, flip sdl.RendererFlip)
+	DrawRectOutline(x, y, w, h int32)
+	Clear()
+	Present()
+	Cleanup()
+}
+
+// SdlGraphics owns the window and the accelerated renderer
+type SdlGraphics struct {
 	engine *Engine
+	window *sdl.Window
+	renderer *sdl.Renderer
+}
+
+func (g *SdlGraphics) Init() error {
+	var err error
+	g.window, err = sdl.CreateWindow(
+		g.engine.Config.WindowTitle,
+		sdl.WINDOWPOS_UNDEFINED,
+		sdl.WINDOWPOS_UNDEFINED,
+		g.engine.Config.WindowWidth,
+		g.engine.Config.WindowHeight,
+		sdl.WINDOW_SHOWN,
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	g.renderer, err = sdl.CreateRenderer(g.window, -1, sdl.RENDERER_ACCELERATED|sdl.RENDERER_PRESENTVSYNC)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create rederer: %s\n", err)
+		return err
+	}
+
+	g.renderer.SetScale(g.engine.Config.Scale, g.engine.Config.Scale)
+	return nil
 }
 
-func (g *Graphics) Load(file string) (*sdl.Texture, error) {
-	i, err := img.LoadTexture(g.engine.renderer, file)
+func (g *SdlGraphics) Load(file string) (*sdl.Texture, error) {
+	i, err := img.LoadTexture(g.renderer, file)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load image: %s\n", err)
 		return nil, err
@@ -20,7 +59,7 @@ func (g *Graphics) Load(file string) (*sdl.Texture, error) {
 	return i, err
 }
 
-func (g *Graphics) DrawFull(texture *sdl.Texture, x int32, y int32) {
+func (g *SdlGraphics) DrawFull(texture *sdl.Texture, x int32, y int32) {
 	_, _, w, h, err := texture.Query()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to draw texture: %s\n", err)
@@ -29,16 +68,28 @@ func (g *Graphics) DrawFull(texture *sdl.Texture, x int32, y int32) {
 	g.DrawPart(texture, x, y, 0, 0, w, h, sdl.FLIP_NONE)
 }
 
-func (g *Graphics) DrawPart(texture *sdl.Texture, x int32, y int32, clipX int32, clipY int32, w int32, h int32, flip sdl.RendererFlip) {
+func (g *SdlGraphics) DrawPart(texture *sdl.Texture, x int32, y int32, clipX int32, clipY int32, w int32, h int32, flip sdl.RendererFlip) {
 	src := sdl.Rect{clipX, clipY, w, h}
 	dst := sdl.Rect{x, y, w, h}
-	g.engine.renderer.CopyEx(texture, &src, &dst, 0.0, nil, flip)
+	g.renderer.CopyEx(texture, &src, &dst, 0.0, nil, flip)
 }
 
-func (g *Graphics) DrawRectOutline(x, y, w, h int32) {
+func (g *SdlGraphics) DrawRectOutline(x, y, w, h int32) {
 	outline := sdl.Rect{X: x, Y: y, W: w, H: h}
-	g.engine.renderer.SetDrawColor(255, 0, 0, 255)
-	g.engine.renderer.DrawRect(&outline)
-	g.engine.renderer.SetDrawColor(0, 0, 0, 255)
+	g.renderer.SetDrawColor(255, 0, 0, 255)
+	g.renderer.DrawRect(&outline)
+	g.renderer.SetDrawColor(0, 0, 0, 255)
+}
+
+func (g *SdlGraphics) Clear() {
+	g.renderer.Clear()
+}
+
+func (g *SdlGraphics) Present() {
+	g.renderer.Present()
 }
 
+func (g *SdlGraphics) Cleanup() {
+	g.renderer.Destroy()
+	g.window.Destroy()
+}
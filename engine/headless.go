@@ -0,0 +1,48 @@
+package engine
+
+import "github.com/veandco/go-sdl2/sdl"
+
+// No-op implementations used when EngineConfig.Headless is set.  Nothing
+// here touches the display, GPU or audio device.
+
+type NullGraphics struct {}
+
+func (g *NullGraphics) Init() error { return nil }
+func (g *NullGraphics) Load(file string) (*sdl.Texture, error) { return nil, nil }
+func (g *NullGraphics) DrawFull(texture *sdl.Texture, x int32, y int32) {}
+func (g *NullGraphics) DrawPart(texture *sdl.Texture, x int32, y int32, clipX int32, clipY int32, w int32, h int32, flip sdl.RendererFlip) {}
+func (g *NullGraphics) DrawRectOutline(x, y, w, h int32) {}
+func (g *NullGraphics) Clear() {}
+func (g *NullGraphics) Present() {}
+func (g *NullGraphics) Cleanup() {}
+
+// NullTextManager remembers the last string written under each name but
+// never creates a texture
+type NullTextManager struct {
+	values map[string]string
+}
+
+func (tm *NullTextManager) Init() { tm.values = make(map[string]string) }
+func (tm *NullTextManager) Write(name string, text string) { tm.values[name] = text }
+func (tm *NullTextManager) GetTexture(name string) *sdl.Texture { return nil }
+func (tm *NullTextManager) Remove(name string) { delete(tm.values, name) }
+func (tm *NullTextManager) Cleanup() {}
+
+// Value returns the last string written under name
+func (tm *NullTextManager) Value(name string) string { return tm.values[name] }
+
+// NullAudioManager records which clips were played instead of playing them
+type NullAudioManager struct {
+	Played []string
+}
+
+func (am *NullAudioManager) Init() {}
+func (am *NullAudioManager) PlayBgMusic(asset string) { am.Played = append(am.Played, asset) }
+func (am *NullAudioManager) PlaySoundEffect(name string) { am.Played = append(am.Played, name) }
+func (am *NullAudioManager) Cleanup() {}
+
+// NullInputSource produces no events.  Keys are fed in directly with
+// InputManager.SetKeyHeld.
+type NullInputSource struct {}
+
+func (s *NullInputSource) PollEvents(engine *Engine) {}
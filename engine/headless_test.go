@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"testing"
+)
+
+// newHeadlessEngine builds a headless engine with the default systems
+// and the repo's prefabs loaded, but no map
+func newHeadlessEngine(t *testing.T, cfg EngineConfig) *Engine {
+	t.Helper()
+	cfg.Headless = true
+	cfg.RootDir = ".."
+	cfg.WindowWidth = 1024
+	cfg.WindowHeight = 768
+	cfg.Scale = 2
+
+	eng, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng.World = NewWorld()
+	if err := eng.World.RegisterSystems(DefaultSystems()...); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.World.LoadPrefabs(eng.File.GetDirectoryPath("assets/prefabs")); err != nil {
+		t.Fatal(err)
+	}
+	return eng
+}
+
+// newHeadlessLevel builds a headless engine with level2 loaded from the
+// repo's assets and returns it with the player entity
+func newHeadlessLevel(t *testing.T, cfg EngineConfig) (*Engine, Entity) {
+	t.Helper()
+	eng := newHeadlessEngine(t, cfg)
+	if err := eng.Map.Load("level2", eng.World); err != nil {
+		t.Fatal(err)
+	}
+
+	player := eng.World.FindByTag("player")
+	if player == ENTITY_NONE {
+		t.Fatal("level2 has no player")
+	}
+	return eng, player
+}
+
+type scriptStep struct {
+	key   sdl.Keycode
+	held  bool
+	ticks int
+}
+
+func runScript(eng *Engine, script []scriptStep) {
+	for _, step := range script {
+		eng.Input.SetKeyHeld(step.key, step.held)
+		eng.Step(step.ticks)
+	}
+}
+
+// the player falls onto the floor, runs right and picks up the coins
+// along the way
+var runRightScript = []scriptStep{
+	{sdl.K_RIGHT, false, 120},
+	{sdl.K_RIGHT, true, 180},
+}
+
+func TestHeadlessLevel(t *testing.T) {
+	eng, player := newHeadlessLevel(t, EngineConfig{})
+	transform := eng.World.GetTransform(player)
+	startX := transform.X
+
+	runScript(eng, runRightScript[:1])
+	if !eng.World.GetState(player).Grounded {
+		t.Fatalf("player should have landed, at %v,%v", transform.X, transform.Y)
+	}
+
+	runScript(eng, runRightScript[1:])
+	if transform.X <= startX {
+		t.Fatalf("player didn't move right: %v -> %v", startX, transform.X)
+	}
+	if !eng.World.GetState(player).Grounded {
+		t.Fatalf("player should still be on the floor, at %v,%v", transform.X, transform.Y)
+	}
+	if transform.X != 359 || transform.Y != 258 {
+		t.Errorf("player ended at %v,%v, want 359,258", transform.X, transform.Y)
+	}
+	if gold := eng.World.GetInventory(player).Items["gold"]; gold != 6 {
+		t.Errorf("player has %d gold, want 6", gold)
+	}
+
+	played := 0
+	for _, clip := range eng.Audio.(*NullAudioManager).Played {
+		if clip == "coin.wav" {
+			played++
+		}
+	}
+	if played != 6 {
+		t.Errorf("coin sound played %d times, want 6: %v", played, eng.Audio.(*NullAudioManager).Played)
+	}
+}
+
+func TestHeadlessDeterministic(t *testing.T) {
+	var ends [2]Transform
+	for i := range ends {
+		eng, player := newHeadlessLevel(t, EngineConfig{Seed: 1})
+		runScript(eng, []scriptStep{
+			{sdl.K_RIGHT, true, 40},
+			{sdl.K_SPACE, true, 3},
+			{sdl.K_SPACE, false, 30},
+			{sdl.K_RIGHT, false, 10},
+			{sdl.K_LEFT, true, 50},
+			{sdl.K_SPACE, true, 5},
+			{sdl.K_SPACE, false, 60},
+		})
+		ends[i] = *eng.World.GetTransform(player)
+		eng.Cleanup()
+	}
+	if ends[0] != ends[1] {
+		t.Fatalf("identical runs ended differently: %+v and %+v", ends[0], ends[1])
+	}
+}
+
+// pauseSystem toggles pause from the input phase
+type pauseSystem struct{}
+
+func (ps *pauseSystem) Schedule() SystemConfig {
+	return SystemConfig{Name: "pause", Phase: PHASE_INPUT}
+}
+func (ps *pauseSystem) Init(world *World) {}
+func (ps *pauseSystem) Update(engine *Engine, world *World) {
+	if engine.Input.KeyState(sdl.K_ESCAPE).JustPressed() {
+		engine.SetPaused(!engine.Paused())
+	}
+}
+
+func TestPauseFromInputPhase(t *testing.T) {
+	eng, player := newHeadlessLevel(t, EngineConfig{})
+	if err := eng.World.RegisterSystem(&pauseSystem{}); err != nil {
+		t.Fatal(err)
+	}
+	eng.Step(120)
+	transform := eng.World.GetTransform(player)
+
+	runScript(eng, []scriptStep{{sdl.K_ESCAPE, true, 1}, {sdl.K_ESCAPE, false, 1}})
+	if !eng.Paused() {
+		t.Fatal("escape didn't pause")
+	}
+	x, y, ticks := transform.X, transform.Y, eng.Ticks()
+	runScript(eng, []scriptStep{{sdl.K_RIGHT, true, 30}})
+	if transform.X != x || transform.Y != y || eng.Ticks() != ticks {
+		t.Fatalf("simulation ran while paused: %v,%v -> %v,%v", x, y, transform.X, transform.Y)
+	}
+
+	runScript(eng, []scriptStep{{sdl.K_ESCAPE, true, 1}, {sdl.K_ESCAPE, false, 1}})
+	if eng.Paused() {
+		t.Fatal("escape didn't unpause")
+	}
+	eng.Step(30)
+	if transform.X <= x {
+		t.Fatalf("player didn't move after unpausing: %v -> %v", x, transform.X)
+	}
+}
+
+func TestReplayPlayback(t *testing.T) {
+	path := t.TempDir() + "/run.json"
+	eng, player := newHeadlessLevel(t, EngineConfig{RecordReplay: path})
+	runScript(eng, []scriptStep{
+		{sdl.K_RIGHT, true, 40},
+		{sdl.K_SPACE, true, 3},
+		{sdl.K_SPACE, false, 30},
+		{sdl.K_RIGHT, false, 10},
+		{sdl.K_LEFT, true, 50},
+	})
+	want := *eng.World.GetTransform(player)
+	eng.Cleanup()
+
+	eng, player = newHeadlessLevel(t, EngineConfig{PlayReplay: path})
+	eng.running = true
+	for eng.running {
+		eng.Step(1)
+	}
+	if got := *eng.World.GetTransform(player); got != want {
+		t.Fatalf("replay ended at %v,%v, recording ended at %v,%v", got.X, got.Y, want.X, want.Y)
+	}
+	if eng.Ticks() != 133 {
+		t.Errorf("replay ran %d ticks, want 133", eng.Ticks())
+	}
+}
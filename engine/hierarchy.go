@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"os"
+)
+
+// parent chains deeper than this are assumed to be broken
+const MAX_HIERARCHY_DEPTH = 32
+
+// SetParent attaches child to parent.  The child's transform is
+// interpreted relative to the parent from then on and the child is
+// destroyed along with the parent.
+func (w *World) SetParent(child, parent Entity) {
+	if !w.Alive(child) || !w.Alive(parent) {
+		fmt.Fprintf(os.Stderr, "SetParent called with dead entity\n")
+		return
+	}
+	for e := parent; e != ENTITY_NONE; e = w.GetParent(e) {
+		if e == child {
+			fmt.Fprintf(os.Stderr, "SetParent would create a cycle: %d -> %d\n", child.Index(), parent.Index())
+			return
+		}
+	}
+	ParentComponent.Add(w, child, Parent{Entity: parent})
+}
+
+// ClearParent detaches the entity, its transform becomes absolute again
+func (w *World) ClearParent(child Entity) {
+	ParentComponent.Remove(w, child)
+}
+
+// GetParent returns the entity's live parent or ENTITY_NONE
+func (w *World) GetParent(entity Entity) Entity {
+	if !ParentComponent.Has(w, entity) {
+		return ENTITY_NONE
+	}
+	parent := ParentComponent.Get(w, entity).Entity
+	if !w.Alive(parent) {
+		return ENTITY_NONE
+	}
+	return parent
+}
+
+func (w *World) GetChildren(parent Entity) []Entity {
+	var children []Entity
+	for _, entity := range w.Query(COMPONENT_PARENT) {
+		if ParentComponent.Get(w, entity).Entity == parent {
+			children = append(children, entity)
+		}
+	}
+	return children
+}
+
+// WorldPosition resolves the entity's transform through its parents
+func (w *World) WorldPosition(entity Entity) (float32, float32) {
+	var x, y float32
+	for depth := 0; entity != ENTITY_NONE && depth < MAX_HIERARCHY_DEPTH; depth++ {
+		transform := w.GetTransform(entity)
+		if transform == nil {
+			break
+		}
+		x += transform.X
+		y += transform.Y
+		entity = w.GetParent(entity)
+	}
+	return x, y
+}
+
+// RenderPosition is WorldPosition interpolated alpha of the way from the
+// previous simulation tick to the current one.  Only entities with
+// COMPONENT_VELOCITY track a previous position, the rest don't move
+// between ticks and are used as is.
+func (w *World) RenderPosition(entity Entity, alpha float32) (float32, float32) {
+	var x, y float32
+	for depth := 0; entity != ENTITY_NONE && depth < MAX_HIERARCHY_DEPTH; depth++ {
+		transform := w.GetTransform(entity)
+		if transform == nil {
+			break
+		}
+		if w.HasComponents(entity, COMPONENT_VELOCITY) {
+			x += transform.PrevX + (transform.X - transform.PrevX) * alpha
+			y += transform.PrevY + (transform.Y - transform.PrevY) * alpha
+		} else {
+			x += transform.X
+			y += transform.Y
+		}
+		entity = w.GetParent(entity)
+	}
+	return x, y
+}
+
+// GetWorldBB is Transform.GetBB at the entity's world position
+func (w *World) GetWorldBB(entity Entity) sdl.Rect {
+	transform := *w.GetTransform(entity)
+	transform.X, transform.Y = w.WorldPosition(entity)
+	return transform.GetBB()
+}
@@ -54,6 +54,33 @@ func (key *KeyState) Down() bool {
 	return key.lastState && key.currentState
 }
 
+// InputSource feeds window and keyboard events to the engine once per frame
+type InputSource interface {
+	PollEvents(engine *Engine)
+}
+
+// SdlInputSource drains the SDL event queue
+type SdlInputSource struct {}
+
+func (s *SdlInputSource) PollEvents(g *Engine) {
+	for e := sdl.PollEvent(); e != nil; e = sdl.PollEvent() {
+		switch t := e.(type) {
+		case *sdl.QuitEvent:
+			g.Stop()
+			break
+		case *sdl.KeyboardEvent:
+			g.Input.OnKeyboardEvent(e.(*sdl.KeyboardEvent))
+			sym := e.(*sdl.KeyboardEvent).Keysym.Sym
+			if sym == sdl.K_q {
+				g.Stop()
+			}
+			if sym == sdl.K_p  && t.State == sdl.RELEASED {
+				g.SetPaused(!g.Paused())
+			}
+		}
+	}
+}
+
 type InputManager struct {
 	KeysHeld map[sdl.Keycode]bool
 	KeyStates map[sdl.Keycode]*KeyState
@@ -70,9 +97,12 @@ func NewInputManager() *InputManager {
 	}
 }
 
+// UpdateKeyStates latches the held keys into their KeyStates.  It runs
+// once per simulation tick so JustPressed and JustReleased are true for
+// exactly one tick no matter how many frames are rendered.
 func (i *InputManager) UpdateKeyStates() {
-	for _, state := range i.KeyStates {
-		state.Set(state.currentState)
+	for key, state := range i.KeyStates {
+		state.Set(i.KeysHeld[key])
 	}
 }
 
@@ -81,18 +111,24 @@ func (i *InputManager) OnKeyboardEvent(evt *sdl.KeyboardEvent) {
 	sym := evt.Keysym.Sym
 
 	if t.State == sdl.PRESSED {
-		i.KeysHeld[sym] = true
-		i.SetKeyState(sym, true)
+		i.SetKeyHeld(sym, true)
 		// i.executeCallbacks(&i.PressedListeners, sym)
 	}
 
 	if t.State == sdl.RELEASED {
-		i.KeysHeld[sym] = false
-		i.SetKeyState(sym, false)
+		i.SetKeyHeld(sym, false)
 		// i.executeCallbacks(&i.ReleasedListeners, sym)
 	}
 }
 
+// SetKeyHeld records a key going down or up, key states pick it up on
+// the next tick.  Input sources other than the SDL event queue (tests,
+// headless runs) feed keys in through here.
+func (i *InputManager) SetKeyHeld(key sdl.Keycode, held bool) {
+	i.KeysHeld[key] = held
+	i.KeyState(key)
+}
+
 func (i *InputManager) KeyState(key sdl.Keycode) *KeyState {
 	if _, ok := i.KeyStates[key]; !ok {
 		i.KeyStates[key] = &KeyState{}
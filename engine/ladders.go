@@ -0,0 +1,22 @@
+package engine
+
+// LadderAt returns the ladder entity covering the point, or ENTITY_NONE
+func (w *World) LadderAt(x, y float32) Entity {
+	return w.FindAt(COMPONENT_LADDER, x, y)
+}
+
+// MarkLadderTops makes the top of each ladder a platform so it can be
+// stood on and climbed down from.  Ladders are usually stacks of entities,
+// only the one with no ladder directly above it is the top.  Map.Load
+// calls this once the map's objects are spawned.
+func (w *World) MarkLadderTops() {
+	for _, entity := range w.Query(COMPONENT_LADDER|COMPONENT_TRANSFORM) {
+		if PlatformComponent.Has(w, entity) {
+			continue
+		}
+		ladder := w.GetTransform(entity)
+		if w.LadderAt(ladder.X + float32(ladder.W) / 2, ladder.Y - 1) == ENTITY_NONE {
+			PlatformComponent.Add(w, entity, Platform{})
+		}
+	}
+}
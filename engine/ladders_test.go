@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"testing"
+)
+
+// newLadderTest builds a headless engine around a map with a floor at
+// y 160 and a ledge at y 80, a ladder from the floor up beside the ledge
+// and the player standing on the floor left of the ladder
+func newLadderTest(t *testing.T) (*Engine, *Transform, *State) {
+	t.Helper()
+	eng := newHeadlessEngine(t, EngineConfig{})
+	eng.Map = newTestMap([]string{
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"........############",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"####################",
+	})
+
+	w := eng.World
+	for y := float32(80); y < 160; y += 16 {
+		w.entityBuilders["ladder"](w, 112, y)
+	}
+	w.MarkLadderTops()
+	player := w.entityBuilders["player"](w, 60, 146)
+	eng.Step(10)
+	return eng, w.GetTransform(player), w.GetState(player)
+}
+
+// stepUntil steps the engine until done returns true, at most ticks times
+func stepUntil(eng *Engine, ticks int, done func() bool) bool {
+	for i := 0; i < ticks; i++ {
+		if done() {
+			return true
+		}
+		eng.Step(1)
+	}
+	return done()
+}
+
+// climbFromBelow walks the player right to the ladder and grabs it
+func climbFromBelow(t *testing.T, eng *Engine, transform *Transform, state *State) {
+	t.Helper()
+	eng.Input.SetKeyHeld(sdl.K_RIGHT, true)
+	if !stepUntil(eng, 100, func() bool { return transform.Sensor.Ladder != ENTITY_NONE }) {
+		t.Fatalf("never reached the ladder, at %v,%v", transform.X, transform.Y)
+	}
+	eng.Input.SetKeyHeld(sdl.K_RIGHT, false)
+	eng.Input.SetKeyHeld(sdl.K_UP, true)
+	eng.Step(2)
+	if !state.Climbing || state.State != ENTITY_STATE_CLIMB {
+		t.Fatalf("not climbing after pressing up, state %v", state.State)
+	}
+	// lined up with the middle of the ladder
+	if transform.X+float32(transform.W)/2 != 120 {
+		t.Errorf("climbing at x %v, not the middle of the ladder", transform.X)
+	}
+}
+
+func TestLadderClimbUpAndOffTheTop(t *testing.T) {
+	eng, transform, state := newLadderTest(t)
+	if !transform.Sensor.Bottom {
+		t.Fatal("player isn't on the floor")
+	}
+	climbFromBelow(t, eng, transform, state)
+
+	y := transform.Y
+	eng.Step(20)
+	if transform.Y >= y {
+		t.Fatalf("didn't climb, y %v to %v", y, transform.Y)
+	}
+	// letting go of up holds on where the player is
+	eng.Input.SetKeyHeld(sdl.K_UP, false)
+	y = transform.Y
+	eng.Step(30)
+	if transform.Y != y || !state.Climbing {
+		t.Fatalf("slid from y %v to %v, climbing %v", y, transform.Y, state.Climbing)
+	}
+
+	// off the top the player stands on the ladder instead of carrying on up
+	eng.Input.SetKeyHeld(sdl.K_UP, true)
+	if !stepUntil(eng, 120, func() bool { return !state.Climbing }) {
+		t.Fatal("still climbing at the top of the ladder")
+	}
+	eng.Input.SetKeyHeld(sdl.K_UP, false)
+	eng.Step(30)
+	if transform.Y+float32(transform.H) != 80 || !transform.Sensor.Bottom {
+		t.Errorf("bottom at %v, want standing on the top of the ladder at 80", transform.Y+float32(transform.H))
+	}
+}
+
+func TestLadderClimbDownFromTheTop(t *testing.T) {
+	eng, transform, state := newLadderTest(t)
+	climbFromBelow(t, eng, transform, state)
+	stepUntil(eng, 120, func() bool { return !state.Climbing })
+	eng.Input.SetKeyHeld(sdl.K_UP, false)
+
+	// walk off onto the ledge and back onto the top of the ladder
+	eng.Input.SetKeyHeld(sdl.K_RIGHT, true)
+	eng.Step(20)
+	eng.Input.SetKeyHeld(sdl.K_RIGHT, false)
+	eng.Step(20)
+	if transform.Y+float32(transform.H) != 80 || transform.Sensor.Ladder != ENTITY_NONE {
+		t.Fatalf("at %v,%v, want on the ledge", transform.X, transform.Y)
+	}
+	eng.Input.SetKeyHeld(sdl.K_LEFT, true)
+	if !stepUntil(eng, 100, func() bool { return transform.Sensor.Platform != ENTITY_NONE }) {
+		t.Fatal("never stood on the top of the ladder")
+	}
+	eng.Input.SetKeyHeld(sdl.K_LEFT, false)
+	eng.Step(1)
+	if transform.Y+float32(transform.H) != 80 {
+		t.Fatalf("fell through the top of the ladder to %v", transform.Y)
+	}
+
+	// down grabs the ladder from the top and climbs through it
+	eng.Input.SetKeyHeld(sdl.K_DOWN, true)
+	eng.Step(3)
+	if !state.Climbing {
+		t.Fatal("down on the top of the ladder didn't grab it")
+	}
+	// reaching the floor holding down lets go
+	if !stepUntil(eng, 150, func() bool { return !state.Climbing }) {
+		t.Fatal("still climbing at the bottom of the ladder")
+	}
+	if transform.Y+float32(transform.H) != 160 {
+		t.Errorf("bottom at %v, want on the floor at 160", transform.Y+float32(transform.H))
+	}
+}
+
+func TestLadderJumpOff(t *testing.T) {
+	eng, transform, state := newLadderTest(t)
+	climbFromBelow(t, eng, transform, state)
+	eng.Step(30)
+
+	eng.Input.SetKeyHeld(sdl.K_SPACE, true)
+	eng.Step(1)
+	eng.Input.SetKeyHeld(sdl.K_UP, false)
+	if state.Climbing {
+		t.Fatal("jumping didn't let go of the ladder")
+	}
+	y := transform.Y
+	eng.Step(5)
+	if transform.Y >= y {
+		t.Fatalf("didn't jump, y %v to %v", y, transform.Y)
+	}
+	eng.Input.SetKeyHeld(sdl.K_SPACE, false)
+	eng.Step(100)
+	if state.Climbing || transform.Y+float32(transform.H) != 160 {
+		t.Errorf("bottom at %v climbing %v, want landed on the floor", transform.Y+float32(transform.H), state.Climbing)
+	}
+}
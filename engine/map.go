@@ -19,13 +19,45 @@ const (
 	TILE_TYPE_NONE = 0
 	TILE_TYPE_NORMAL = 1
 	TILE_TYPE_BLOCK = 2
+	// blocks only entities landing on it from above
+	TILE_TYPE_ONEWAY = 3
+	// floor slopes, see slopes.go.  45 degree slopes fill one tile,
+	// 22.5 degree slopes take a low and a high tile.
+	TILE_TYPE_SLOPE_UP = 4
+	TILE_TYPE_SLOPE_DOWN = 5
+	TILE_TYPE_SLOPE_UP_LOW = 6
+	TILE_TYPE_SLOPE_UP_HIGH = 7
+	TILE_TYPE_SLOPE_DOWN_HIGH = 8
+	TILE_TYPE_SLOPE_DOWN_LOW = 9
 )
 
+// tile types are set with a "type" property on tiles in the tileset,
+// tiles without one are blocks.  The blank tile in tilemap.tsx is one-way
+// so a row of them can sit under the bridge prefab, and the floor slope
+// tiles are typed to match their art.
+var tileTypeNames = map[string]int32{
+	"none":            TILE_TYPE_NONE,
+	"normal":          TILE_TYPE_NORMAL,
+	"block":           TILE_TYPE_BLOCK,
+	"oneway":          TILE_TYPE_ONEWAY,
+	"slope_up":        TILE_TYPE_SLOPE_UP,
+	"slope_down":      TILE_TYPE_SLOPE_DOWN,
+	"slope_up_low":    TILE_TYPE_SLOPE_UP_LOW,
+	"slope_up_high":   TILE_TYPE_SLOPE_UP_HIGH,
+	"slope_down_high": TILE_TYPE_SLOPE_DOWN_HIGH,
+	"slope_down_low":  TILE_TYPE_SLOPE_DOWN_LOW,
+}
+
 type Tile struct {
 	TileID int32
 	TypeID int32
 }
 func (t *Tile) IsSolid() bool { return t.TypeID == TILE_TYPE_BLOCK }
+func (t *Tile) IsOneWay() bool { return t.TypeID == TILE_TYPE_ONEWAY }
+func (t *Tile) IsSlope() bool {
+	_, ok := slopeHeights[t.TypeID]
+	return ok
+}
 
 func (m *Map) Load(mapName string, world *World) error {
 	var err error
@@ -57,13 +89,30 @@ func (m *Map) Load(mapName string, world *World) error {
 		fmt.Println(err)
 		fmt.Println(group)
 	}
-	for _, obj := range group.Objects {
+	for i := range group.Objects {
+		obj := &group.Objects[i]
+		// untyped objects only mark places for other objects, e.g. where
+		// a platform's path ends
+		if obj.Type == "" {
+			continue
+		}
 		if builder, ok := world.entityBuilders[obj.Type]; ok {
-			builder(world, float32(obj.X + (obj.Width / 2)), float32(obj.Y - obj.Height))
+			entity := builder(world, float32(obj.X + (obj.Width / 2)), float32(obj.Y - obj.Height))
+			if world.HasComponents(entity, COMPONENT_PLATFORM|COMPONENT_TRANSFORM) {
+				setupPlatform(&tmx, obj, world, entity)
+			}
+			// water takes the size of the rectangle drawn in the map
+			if world.HasComponents(entity, COMPONENT_WATER|COMPONENT_TRANSFORM) {
+				transform := world.GetTransform(entity)
+				transform.X, transform.Y = float32(obj.X), float32(obj.Y)
+				transform.PrevX, transform.PrevY = transform.X, transform.Y
+				transform.W, transform.H = int32(obj.Width), int32(obj.Height)
+			}
 		} else {
 			fmt.Fprintf(os.Stderr, "No entity builder registered for type: %s\n", obj.Type)
 		}
 	}
+	world.MarkLadderTops()
 
 	for _, v := range layer.Data.ParsedData {
 		var tileId, typeId int32
@@ -71,7 +120,7 @@ func (m *Map) Load(mapName string, world *World) error {
 			typeId = TILE_TYPE_NONE
 		} else {
 			tileId = int32(v - 1)
-			typeId = TILE_TYPE_BLOCK
+			typeId = tileType(&tmx, v)
 		}
 		tmpTile := Tile{}
 		tmpTile.TileID = tileId
@@ -81,19 +130,37 @@ func (m *Map) Load(mapName string, world *World) error {
 	return nil
 }
 
+// tileType looks up the type property of the tile with global id gid
+func tileType(tmx *TmxMap, gid int) int32 {
+	tileset, id := tmx.GetTilesetByGid(gid)
+	if tileset == nil {
+		return TILE_TYPE_BLOCK
+	}
+	name, ok := tileset.TileProperty(id, "type")
+	if !ok {
+		return TILE_TYPE_BLOCK
+	}
+	typeId, ok := tileTypeNames[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown tile type %s in tileset %s\n", name, tileset.Name)
+		return TILE_TYPE_BLOCK
+	}
+	return typeId
+}
+
 func (m *Map) Render(mapX int32, mapY int32) {
 	if m.Texture == nil {
 		return
 	}
 
-	_, _, w, h, err := m.Texture.Query()
+	_, _, w, _, err := m.Texture.Query()
 	if err != nil {
 		fmt.Print(err)
 		panic(err)
 	}
 
+	// tiles are numbered across each row of the tileset image
 	tilesetWidth := w / m.TileSize
-	tilesetHeight := h / m.TileSize
 
 	id := 0
 
@@ -109,7 +176,7 @@ func (m *Map) Render(mapX int32, mapY int32) {
 			tY := mapY + int32(y * m.TileSize)
 
 			tilesetX := (m.tileList[id].TileID % tilesetWidth) * m.TileSize
-			tilesetY := (m.tileList[id].TileID / tilesetHeight) * m.TileSize
+			tilesetY := (m.tileList[id].TileID / tilesetWidth) * m.TileSize
 
 			m.engine.Graphics.DrawPart(m.Texture, tX, tY, tilesetX, tilesetY, m.TileSize, m.TileSize, sdl.FLIP_NONE)
 
@@ -137,6 +204,17 @@ func (m *Map) PointCollidesTile(x, y int32) bool {
 	}
 }
 
+// PointOnOneWayTop reports whether the point is inside a one-way tile
+// and bottom, the bottom edge of a box, is at or above the tile's top.
+// Boxes part way through a one-way tile aren't standing on it.
+func (m *Map) PointOnOneWayTop(x, y int32, bottom float32) bool {
+	tile := m.GetTile(x, y)
+	if tile == nil || !tile.IsOneWay() {
+		return false
+	}
+	return bottom <= float32(floorDiv(y, m.TileSize) * m.TileSize)
+}
+
 func (m *Map) Cleanup() {
 	m.Texture.Destroy()
 }
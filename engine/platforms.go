@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// easings map progress along a leg of a platform's path, 0 to 1, to how
+// far along it the platform is
+var easings = map[string]func(t float32) float32{
+	"":            func(t float32) float32 { return t },
+	"linear":      func(t float32) float32 { return t },
+	"ease-in":     func(t float32) float32 { return t * t },
+	"ease-out":    func(t float32) float32 { return t * (2 - t) },
+	"ease-in-out": func(t float32) float32 { return t * t * (3 - 2*t) },
+}
+
+// legs is the number of legs in the path, a looping path has one more
+// from the last point back to the first
+func (p *Platform) legs() int {
+	if len(p.Path) < 2 {
+		return 0
+	}
+	if p.Loop {
+		return len(p.Path)
+	}
+	return len(p.Path) - 1
+}
+
+func (p *Platform) leg() (PathPoint, PathPoint) {
+	return p.Path[p.Leg], p.Path[(p.Leg+1)%len(p.Path)]
+}
+
+// Position is where the middle of the platform is on its path
+func (p *Platform) Position() (float32, float32) {
+	if len(p.Path) == 0 {
+		return 0, 0
+	}
+	if p.legs() == 0 || p.Leg >= p.legs() {
+		return p.Path[0].X, p.Path[0].Y
+	}
+	ease, ok := easings[p.Easing]
+	if !ok {
+		ease = easings["linear"]
+	}
+	from, to := p.leg()
+	t := ease(p.Progress)
+	return from.X + (to.X-from.X)*t, from.Y + (to.Y-from.Y)*t
+}
+
+// Advance moves the platform distance pixels along its path, turning
+// round or looping at the end.  Speed is the average over each leg, the
+// easing moves the platform faster in the middle.
+func (p *Platform) Advance(distance float32) {
+	legs := p.legs()
+	if legs == 0 {
+		return
+	}
+	if p.Leg >= legs {
+		p.Leg, p.Progress = 0, 0
+	}
+	// zero length legs are skipped, the cap stops a path made of them
+	// from spinning forever
+	for i := 0; i <= 2*legs && distance > 0; i++ {
+		from, to := p.leg()
+		length := float32(math.Hypot(float64(to.X-from.X), float64(to.Y-from.Y)))
+		left := (1 - p.Progress) * length
+		if p.Reverse {
+			left = p.Progress * length
+		}
+		if distance < left {
+			if p.Reverse {
+				p.Progress -= distance / length
+			} else {
+				p.Progress += distance / length
+			}
+			return
+		}
+		distance -= left
+		p.nextLeg(legs)
+	}
+}
+
+func (p *Platform) nextLeg(legs int) {
+	switch {
+	case !p.Reverse && p.Leg+1 < legs:
+		p.Leg, p.Progress = p.Leg+1, 0
+	case !p.Reverse && p.Loop:
+		p.Leg, p.Progress = 0, 0
+	case !p.Reverse:
+		p.Reverse, p.Progress = true, 1
+	case p.Leg > 0:
+		p.Leg, p.Progress = p.Leg-1, 1
+	default:
+		p.Reverse, p.Progress = false, 0
+	}
+}
+
+// PlacePlatform moves a platform entity to its current point on its path
+func (w *World) PlacePlatform(entity Entity) {
+	platform := w.GetPlatform(entity)
+	transform := w.GetTransform(entity)
+	if len(platform.Path) == 0 {
+		return
+	}
+	x, y := platform.Position()
+	transform.X = x - float32(transform.W)/2
+	transform.Y = y - float32(transform.H)/2
+}
+
+// setupPlatform gives a platform spawned from a map object its path.  The
+// path is the object's polyline, or runs from the middle of the object to
+// the object its "end" property refers to.  speed, easing and loop
+// properties on the object override the prefab's.
+func setupPlatform(tmx *TmxMap, obj *TmxObject, world *World, entity Entity) {
+	platform := world.GetPlatform(entity)
+	path, err := objectPath(tmx, obj)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Platform object %d: %s\n", obj.Id, err)
+	}
+	if len(path) > 0 {
+		platform.Path = path
+	}
+
+	if value, ok := obj.Property("speed"); ok {
+		if speed, err := strconv.ParseFloat(value, 32); err == nil {
+			platform.Speed = float32(speed)
+		} else {
+			fmt.Fprintf(os.Stderr, "Platform object %d: bad speed: %s\n", obj.Id, value)
+		}
+	}
+	if value, ok := obj.Property("easing"); ok {
+		platform.Easing = value
+	}
+	if _, ok := easings[platform.Easing]; !ok {
+		fmt.Fprintf(os.Stderr, "Platform object %d: unknown easing: %s\n", obj.Id, platform.Easing)
+	}
+	if value, ok := obj.Property("loop"); ok {
+		platform.Loop = value == "true"
+	}
+	world.PlacePlatform(entity)
+	transform := world.GetTransform(entity)
+	transform.PrevX = transform.X
+	transform.PrevY = transform.Y
+
+	if platform.Marker == "" {
+		return
+	}
+	builder, ok := world.entityBuilders[platform.Marker]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "No entity builder registered for platform marker: %s\n", platform.Marker)
+		return
+	}
+	for _, point := range platform.Path {
+		marker := builder(world, point.X, point.Y)
+		// centre the marker on the point
+		if transform := world.GetTransform(marker); world.HasComponents(marker, COMPONENT_TRANSFORM) {
+			transform.X -= float32(transform.W) / 2
+			transform.Y -= float32(transform.H) / 2
+			transform.PrevX = transform.X
+			transform.PrevY = transform.Y
+		}
+	}
+}
+
+func objectPath(tmx *TmxMap, obj *TmxObject) ([]PathPoint, error) {
+	if obj.Polyline != nil {
+		return obj.PolylinePoints()
+	}
+	value, ok := obj.Property("end")
+	if !ok {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("bad end object: %s", value)
+	}
+	end := tmx.GetObjectById(id)
+	if end == nil {
+		return nil, fmt.Errorf("no end object with id %d", id)
+	}
+	startX, startY := obj.Center()
+	endX, endY := end.Center()
+	return []PathPoint{{X: startX, Y: startY}, {X: endX, Y: endY}}, nil
+}
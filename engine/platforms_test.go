@@ -0,0 +1,194 @@
+package engine
+
+import "testing"
+
+func checkPosition(t *testing.T, name string, p *Platform, x, y float32) {
+	t.Helper()
+	if gotX, gotY := p.Position(); gotX != x || gotY != y {
+		t.Errorf("%s: at %v,%v, want %v,%v", name, gotX, gotY, x, y)
+	}
+}
+
+func TestPlatformBackAndForth(t *testing.T) {
+	p := &Platform{Path: []PathPoint{{0, 0}, {100, 0}, {100, 50}}}
+	checkPosition(t, "start", p, 0, 0)
+	p.Advance(50)
+	checkPosition(t, "first leg", p, 50, 0)
+	p.Advance(75)
+	checkPosition(t, "second leg", p, 100, 25)
+	// past the end it turns round and comes back the same way
+	p.Advance(50)
+	checkPosition(t, "turned at the end", p, 100, 25)
+	if !p.Reverse {
+		t.Error("not reversing after the end of the path")
+	}
+	p.Advance(75)
+	checkPosition(t, "back along the first leg", p, 50, 0)
+	p.Advance(75)
+	checkPosition(t, "turned at the start", p, 25, 0)
+	if p.Reverse {
+		t.Error("still reversing after the start of the path")
+	}
+}
+
+func TestPlatformLoop(t *testing.T) {
+	p := &Platform{Path: []PathPoint{{0, 0}, {100, 0}, {100, 100}, {0, 100}}, Loop: true}
+	p.Advance(350)
+	// on the leg from the last point back to the first
+	checkPosition(t, "closing leg", p, 0, 50)
+	p.Advance(50)
+	checkPosition(t, "back at the start", p, 0, 0)
+	p.Advance(25)
+	checkPosition(t, "round again", p, 25, 0)
+	if p.Reverse || p.Leg != 0 {
+		t.Errorf("on leg %d, reverse %v, want leg 0 forwards", p.Leg, p.Reverse)
+	}
+}
+
+func TestPlatformEasing(t *testing.T) {
+	// a quarter of the way along each leg
+	cases := map[string]float32{
+		"linear":      25,
+		"ease-in":     6.25,
+		"ease-out":    43.75,
+		"ease-in-out": 15.625,
+	}
+	for easing, want := range cases {
+		p := &Platform{Path: []PathPoint{{0, 0}, {100, 0}, {100, 100}}, Easing: easing}
+		p.Advance(25)
+		checkPosition(t, easing+" first leg", p, want, 0)
+		p.Advance(100)
+		checkPosition(t, easing+" second leg", p, 100, want)
+		// reversing runs the leg's easing backwards from its end
+		p.Advance(150)
+		checkPosition(t, easing+" reversed", p, 100, want)
+	}
+}
+
+// newPlatformTest sets up a map with a 32x8 platform moving along path and
+// an 8x14 rider standing in the middle of it
+func newPlatformTest(rows []string, path []PathPoint, speed float32) (*Engine, *World, Entity, Entity) {
+	eng := &Engine{Map: newTestMap(rows)}
+	world := NewWorld()
+
+	platform := world.CreateEntity()
+	world.SetMask(platform, COMPONENT_TRANSFORM|COMPONENT_PLATFORM)
+	platformTransform := world.GetTransform(platform)
+	platformTransform.W, platformTransform.H = 32, 8
+	*world.GetPlatform(platform) = Platform{Path: path, Speed: speed}
+	world.PlacePlatform(platform)
+	platformTransform.PrevX, platformTransform.PrevY = platformTransform.X, platformTransform.Y
+
+	rider := world.CreateEntity()
+	world.SetMask(rider, COMPONENT_TRANSFORM|COMPONENT_VELOCITY|COMPONENT_STATE)
+	riderTransform := world.GetTransform(rider)
+	riderTransform.W, riderTransform.H = 8, 14
+	riderTransform.X = path[0].X - 4
+	riderTransform.Y = platformTransform.Y - 14
+	// land on the platform before it starts moving
+	(&MovementSystem{}).Update(eng, world)
+	return eng, world, platform, rider
+}
+
+// stepPlatforms runs the platform and movement systems for ticks ticks
+// with gravity pulling the rider down
+func stepPlatforms(eng *Engine, world *World, rider Entity, ticks int) {
+	platforms := &PlatformSystem{}
+	movement := &MovementSystem{}
+	transform := world.GetTransform(rider)
+	state := world.GetState(rider)
+	for i := 0; i < ticks; i++ {
+		state.Grounded = transform.Sensor.Bottom
+		transform.SpeedY += GRAVITY
+		platforms.Update(eng, world)
+		movement.Update(eng, world)
+	}
+}
+
+func TestPlatformCarriesRider(t *testing.T) {
+	rows := []string{
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"############",
+	}
+	eng, world, platform, rider := newPlatformTest(rows, []PathPoint{{40, 60}, {140, 20}}, 1)
+	riderTransform := world.GetTransform(rider)
+	platformTransform := world.GetTransform(platform)
+	if riderTransform.Sensor.Platform != platform {
+		t.Fatal("rider isn't standing on the platform")
+	}
+	offset := riderTransform.X - platformTransform.X
+
+	stepPlatforms(eng, world, rider, 60)
+	if riderTransform.Sensor.Platform != platform {
+		t.Fatal("rider fell off the platform")
+	}
+	if riderTransform.X-platformTransform.X != offset || riderTransform.Y+14 != platformTransform.Y {
+		t.Errorf("rider at %v,%v, platform at %v,%v", riderTransform.X, riderTransform.Y, platformTransform.X, platformTransform.Y)
+	}
+}
+
+func TestPlatformRiderStopsAtWall(t *testing.T) {
+	// the wall at x 96 is level with the rider but above the platform
+	rows := []string{
+		"............",
+		"............",
+		"......#.....",
+		"............",
+		"............",
+		"############",
+	}
+	eng, world, platform, rider := newPlatformTest(rows, []PathPoint{{40, 60}, {140, 60}}, 1)
+	riderTransform := world.GetTransform(rider)
+
+	stepPlatforms(eng, world, rider, 100)
+	if riderTransform.X != 88 {
+		t.Errorf("rider at x %v, want stopped against the wall at 88", riderTransform.X)
+	}
+	// the platform carried on underneath and left the rider behind
+	if riderTransform.Sensor.Platform == platform || riderTransform.Y+14 != 80 {
+		t.Errorf("rider at %v,%v, want on the floor", riderTransform.X, riderTransform.Y)
+	}
+}
+
+func TestPlatformDropThrough(t *testing.T) {
+	rows := []string{
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"############",
+	}
+	eng, world, platform, rider := newPlatformTest(rows, []PathPoint{{40, 40}, {140, 40}}, 1)
+	riderTransform := world.GetTransform(rider)
+	stepPlatforms(eng, world, rider, 10)
+
+	// as the input system does for down and jump
+	world.GetState(rider).Dropping = true
+	stepPlatforms(eng, world, rider, 60)
+	if riderTransform.Sensor.Platform == platform || riderTransform.Y+14 != 80 {
+		t.Errorf("rider at %v,%v, want through the platform onto the floor", riderTransform.X, riderTransform.Y)
+	}
+}
+
+func TestLevelPlatform(t *testing.T) {
+	eng, _ := newHeadlessLevel(t, EngineConfig{})
+	platforms := eng.World.Query(COMPONENT_PLATFORM | COMPONENT_TRANSFORM)
+	if len(platforms) != 1 {
+		t.Fatalf("level2 has %d platforms, want 1", len(platforms))
+	}
+	platform := eng.World.GetPlatform(platforms[0])
+	want := []PathPoint{{440, 544}, {584, 544}}
+	if len(platform.Path) != 2 || platform.Path[0] != want[0] || platform.Path[1] != want[1] {
+		t.Fatalf("path %v, want %v", platform.Path, want)
+	}
+	transform := eng.World.GetTransform(platforms[0])
+	eng.Step(60)
+	if x := transform.X + float32(transform.W)/2; x <= want[0].X || x >= want[1].X || transform.Y != 544-6.5 {
+		t.Errorf("platform at %v,%v after a second", transform.X, transform.Y)
+	}
+}
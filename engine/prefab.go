@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Prefab is an entity template loaded from a json file.  Components are
+// keyed by their registered name and hold the component's initial values,
+// the entity's mask is built from whichever components are listed:
+//
+//	{
+//		"name": "coin",
+//		"components": {
+//			"transform": { "W": 8, "H": 8 },
+//			"tag": { "Values": ["coin"] },
+//			"animation": {
+//				"AnimationStates": {
+//					"idle": { "Asset": "Items/Coin/Shine", "FrameRate": 200, "Infinite": true }
+//				}
+//			}
+//		}
+//	}
+type Prefab struct {
+	Name       string                     `json:"name"`
+	Components map[string]json.RawMessage `json:"components"`
+}
+
+func LoadPrefab(path string) (*Prefab, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var prefab Prefab
+	if err := json.Unmarshal(b, &prefab); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if prefab.Name == "" {
+		return nil, fmt.Errorf("%s: prefab has no name", path)
+	}
+	// catch typos at load time rather than when the map spawns the prefab
+	for name, data := range prefab.Components {
+		id, ok := componentByName(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: unregistered component: %s", path, name)
+		}
+		scratch := components[id].newStore()
+		scratch.grow(1)
+		if err := scratch.unmarshal(0, data); err != nil {
+			return nil, fmt.Errorf("%s: component %s: %s", path, name, err)
+		}
+	}
+	return &prefab, nil
+}
+
+// Build creates an entity from the prefab with its transform at x, y.  It
+// has the EntityBuilder signature so prefabs can be spawned from maps.
+func (p *Prefab) Build(world *World, x, y float32) Entity {
+	entity := world.CreateEntity()
+	var mask uint64
+	for name, data := range p.Components {
+		id, _ := componentByName(name)
+		if err := world.store(id).unmarshal(entity.Index(), data); err != nil {
+			fmt.Fprintf(os.Stderr, "Prefab %s component %s: %s\n", p.Name, name, err)
+			continue
+		}
+		mask |= components[id].bit
+	}
+	world.SetMask(entity, mask)
+
+	if transform := world.GetTransform(entity); signatureMatches(mask, COMPONENT_TRANSFORM) {
+		transform.X = x
+		transform.Y = y
+		transform.PrevX = x
+		transform.PrevY = y
+	}
+	return entity
+}
+
+// LoadPrefabs loads every .json file in dir and registers each prefab as
+// an entity builder under its name
+func (w *World) LoadPrefabs(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		prefab, err := LoadPrefab(path)
+		if err != nil {
+			return err
+		}
+		w.RegisterEntityBuilder(prefab.Name, prefab.Build)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package engine
+
+import "sort"
+
+// query caches the live entities whose mask contains a signature.
+// The member list is kept sorted by slot index so systems see entities
+// in the same order the old full Mask scan did.
+type query struct {
+	signature uint64
+	entities  []Entity
+}
+
+func newQuery(signature uint64) *query {
+	return &query{signature: signature}
+}
+
+// update adds or removes the entity when its mask changes from old to mask
+func (q *query) update(entity Entity, old, mask uint64) {
+	was := old != COMPONENT_NONE && signatureMatches(old, q.signature)
+	is := mask != COMPONENT_NONE && signatureMatches(mask, q.signature)
+	if was == is {
+		return
+	}
+
+	if is {
+		q.entities = insertEntity(q.entities, entity)
+	} else {
+		q.entities = removeEntity(q.entities, entity)
+	}
+}
+
+// insertEntity and removeEntity maintain entity lists sorted by slot
+// index.  They return a copy rather than editing in place so a slice
+// handed out earlier stays valid while a system is still ranging over it.
+func insertEntity(entities []Entity, entity Entity) []Entity {
+	pos := searchEntity(entities, entity)
+	if pos < len(entities) && entities[pos] == entity {
+		return entities
+	}
+	list := make([]Entity, 0, len(entities)+1)
+	list = append(list, entities[:pos]...)
+	list = append(list, entity)
+	return append(list, entities[pos:]...)
+}
+
+func removeEntity(entities []Entity, entity Entity) []Entity {
+	pos := searchEntity(entities, entity)
+	if pos >= len(entities) || entities[pos] != entity {
+		return entities
+	}
+	list := make([]Entity, 0, len(entities))
+	list = append(list, entities[:pos]...)
+	return append(list, entities[pos+1:]...)
+}
+
+func searchEntity(entities []Entity, entity Entity) int {
+	return sort.Search(len(entities), func(i int) bool {
+		return entities[i].Index() >= entity.Index()
+	})
+}
+
+// Query returns every live entity whose mask contains all the bits in
+// signature.  The first call for a signature scans the world; afterwards
+// the list is maintained as masks change.  The returned slice must not be
+// modified, but it is safe to keep ranging over it while entities are
+// created or destroyed.
+func (w *World) Query(signature uint64) []Entity {
+	if q, ok := w.queries[signature]; ok {
+		return q.entities
+	}
+
+	q := newQuery(signature)
+	for index, mask := range w.mask {
+		if mask != COMPONENT_NONE && signatureMatches(mask, signature) {
+			q.entities = append(q.entities, w.EntityAt(index))
+		}
+	}
+	w.queries[signature] = q
+	return q.entities
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+)
+
+// componentStore is the untyped view of a component's per-world storage
+// that the World uses for slot bookkeeping.
+type componentStore interface {
+	grow(n int)
+	clear(index int)
+	marshal(index int) (json.RawMessage, error)
+	unmarshal(index int, data json.RawMessage) error
+}
+
+type store[T any] struct {
+	data []T
+}
+
+func (s *store[T]) grow(n int) {
+	s.data = append(s.data, make([]T, n)...)
+}
+
+func (s *store[T]) clear(index int) {
+	var zero T
+	s.data[index] = zero
+}
+
+func (s *store[T]) marshal(index int) (json.RawMessage, error) {
+	return json.Marshal(s.data[index])
+}
+
+// unknown fields are an error so a renamed or misspelled field in a
+// snapshot or prefab fails loudly instead of being dropped
+func (s *store[T]) unmarshal(index int, data json.RawMessage) error {
+	return decodeStrict(data, &s.data[index])
+}
+
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// componentInfo is the global registry entry for a component type
+type componentInfo struct {
+	name     string
+	bit      uint64
+	newStore func() componentStore
+}
+
+var (
+	components []componentInfo
+	// bits that can't be handed out: the legacy POSITION and APPEARANCE
+	// flags are still declared but have no data behind them
+	usedComponentBits uint64 = COMPONENT_POSITION | COMPONENT_APPEARANCE
+)
+
+// ComponentType is a handle to a registered component.  It holds the mask
+// bit assigned at registration and gives typed access to the component's
+// storage in any World.
+type ComponentType[T any] struct {
+	id   int
+	Bit  uint64
+	Name string
+}
+
+// RegisterComponent declares a new component type and assigns it the next
+// free mask bit.  Games call this once at startup, typically from a package
+// level var, before any entities are built:
+//
+//	var EnemyComponent = engine.RegisterComponent[Enemy]("enemy")
+//
+// It panics if the name is taken or all 64 mask bits are in use.
+func RegisterComponent[T any](name string) *ComponentType[T] {
+	free := ^usedComponentBits
+	if free == 0 {
+		panic(fmt.Sprintf("no component bits left to register %s", name))
+	}
+	return registerComponent[T](name, 1<<uint(bits.TrailingZeros64(free)))
+}
+
+func registerComponent[T any](name string, bit uint64) *ComponentType[T] {
+	for _, info := range components {
+		if info.name == name {
+			panic(fmt.Sprintf("component %s registered twice", name))
+		}
+	}
+	if usedComponentBits&bit != 0 {
+		panic(fmt.Sprintf("component bit %d for %s already in use", bit, name))
+	}
+	usedComponentBits |= bit
+
+	components = append(components, componentInfo{
+		name:     name,
+		bit:      bit,
+		newStore: func() componentStore { return &store[T]{} },
+	})
+	return &ComponentType[T]{
+		id:   len(components) - 1,
+		Bit:  bit,
+		Name: name,
+	}
+}
+
+// componentByName looks up a registered component's id
+func componentByName(name string) (int, bool) {
+	for id, info := range components {
+		if info.name == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (c *ComponentType[T]) storage(w *World) *store[T] {
+	return w.store(c.id).(*store[T])
+}
+
+// Get returns the entity's component data, or nil if the entity is dead.
+// Storage exists for every live entity so the pointer is valid even when
+// the component's bit isn't set; use Has to check membership.
+func (c *ComponentType[T]) Get(w *World, entity Entity) *T {
+	if !w.Alive(entity) {
+		return nil
+	}
+	return &c.storage(w).data[entity.Index()]
+}
+
+// Add stores value on the entity and sets the component's mask bit
+func (c *ComponentType[T]) Add(w *World, entity Entity, value T) *T {
+	cmp := c.Get(w, entity)
+	if cmp == nil {
+		return nil
+	}
+	// replacing goes through a remove so anything watching mask
+	// changes (e.g. the tag index) sees the old value leave
+	if c.Has(w, entity) {
+		w.RemoveComponents(entity, c.Bit)
+	}
+	*cmp = value
+	w.AddComponents(entity, c.Bit)
+	return cmp
+}
+
+// Remove clears the component's mask bit and zeroes its data
+func (c *ComponentType[T]) Remove(w *World, entity Entity) {
+	if !w.Alive(entity) {
+		return
+	}
+	w.RemoveComponents(entity, c.Bit)
+	c.storage(w).clear(entity.Index())
+}
+
+func (c *ComponentType[T]) Has(w *World, entity Entity) bool {
+	return w.HasComponents(entity, c.Bit)
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"io/ioutil"
+	"sort"
+)
+
+// bump whenever the replay layout changes
+const REPLAY_VERSION = 1
+
+// Replay is everything needed to reproduce a run: the seed for
+// Engine.Rand, the tick rate it was recorded at and the keys held during
+// every simulation tick.  Randomness from anywhere other than
+// Engine.Rand isn't captured.
+type Replay struct {
+	Version  int          `json:"version"`
+	Seed     int64        `json:"seed"`
+	TickRate int          `json:"tickRate"`
+	Ticks    []ReplayTick `json:"ticks"`
+}
+
+// ReplayTick is one simulation tick.  Every tick is 1/TICK_RATE of a
+// second so no duration is stored.
+type ReplayTick struct {
+	Keys   []sdl.Keycode `json:"keys,omitempty"`
+	Paused bool          `json:"paused,omitempty"`
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var replay Replay
+	if err := json.Unmarshal(b, &replay); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if replay.Version < 1 || replay.Version > REPLAY_VERSION {
+		return nil, fmt.Errorf("%s: unsupported replay version: %d", path, replay.Version)
+	}
+	if replay.TickRate != TICK_RATE {
+		return nil, fmt.Errorf("%s: recorded at %d ticks per second, the engine runs at %d", path, replay.TickRate, TICK_RATE)
+	}
+	return &replay, nil
+}
+
+func (r *Replay) Save(path string) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// record appends the input state the engine is about to simulate
+func (r *Replay) record(engine *Engine) {
+	var keys []sdl.Keycode
+	for key, held := range engine.Input.KeysHeld {
+		if held {
+			keys = append(keys, key)
+		}
+	}
+	// map order is random, keep files stable
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	r.Ticks = append(r.Ticks, ReplayTick{
+		Keys:   keys,
+		Paused: engine.Paused(),
+	})
+}
+
+// ReplayPlayer feeds a recorded run back into the engine one tick at a time
+type ReplayPlayer struct {
+	replay *Replay
+	tick   int
+}
+
+func NewReplayPlayer(replay *Replay) *ReplayPlayer {
+	return &ReplayPlayer{replay: replay}
+}
+
+func (p *ReplayPlayer) Done() bool {
+	return p.tick >= len(p.replay.Ticks)
+}
+
+// apply replaces the engine's held keys and pause state with the next
+// recorded tick.  Live keyboard input is overwritten every tick.
+func (p *ReplayPlayer) apply(engine *Engine) {
+	tick := p.replay.Ticks[p.tick]
+	p.tick++
+
+	held := make(map[sdl.Keycode]bool)
+	for _, key := range tick.Keys {
+		held[key] = true
+	}
+	for key := range engine.Input.KeysHeld {
+		if !held[key] {
+			engine.Input.SetKeyHeld(key, false)
+		}
+	}
+	for key := range held {
+		engine.Input.SetKeyHeld(key, true)
+	}
+
+	if tick.Paused != engine.Paused() {
+		engine.SetPaused(tick.Paused)
+	}
+}
@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SystemPhase int
+
+// phases run in this order every frame
+const (
+	PHASE_INPUT SystemPhase = iota
+	PHASE_SIMULATION
+	PHASE_POST_SIMULATION
+	PHASE_RENDER
+	PHASE_HUD
+	PHASE_COUNT
+)
+
+var phaseNames = [PHASE_COUNT]string{"input", "simulation", "post-simulation", "render", "hud"}
+
+func (p SystemPhase) String() string {
+	if p < 0 || p >= PHASE_COUNT {
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// SystemConfig describes where a system runs.  Before and After name other
+// systems in the same phase; constraints on systems that haven't been
+// registered yet are checked when they are.
+type SystemConfig struct {
+	Name   string
+	Phase  SystemPhase
+	Before []string
+	After  []string
+}
+
+// Scheduled is implemented by systems that declare their own schedule.
+// Systems that don't are named after their type and run in the
+// simulation phase.
+type Scheduled interface {
+	Schedule() SystemConfig
+}
+
+type scheduledSystem struct {
+	System
+	config  SystemConfig
+	enabled bool
+	// registration order, used to break ties when sorting
+	order int
+}
+
+// RegisterSystem adds a system to the schedule.  It returns an error, and
+// the system is not added, if the name is taken or the ordering constraints
+// can't be satisfied.
+func (w *World) RegisterSystem(system System) error {
+	var config SystemConfig
+	if s, ok := system.(Scheduled); ok {
+		config = s.Schedule()
+	} else {
+		config = SystemConfig{Phase: PHASE_SIMULATION}
+	}
+	if config.Name == "" {
+		config.Name = fmt.Sprintf("%T", system)
+	}
+	if config.Phase < 0 || config.Phase >= PHASE_COUNT {
+		return fmt.Errorf("system %s has invalid phase %d", config.Name, config.Phase)
+	}
+	if w.getSystem(config.Name) != nil {
+		return fmt.Errorf("system %s already registered", config.Name)
+	}
+
+	candidate := &scheduledSystem{
+		System:  system,
+		config:  config,
+		enabled: true,
+		order:   len(w.systems),
+	}
+	sorted, err := sortSystems(append(append([]*scheduledSystem{}, w.systems...), candidate))
+	if err != nil {
+		return err
+	}
+
+	system.Init(w)
+	w.systems = sorted
+	return nil
+}
+
+// RegisterSystems registers each system in turn and then checks the
+// schedule with ValidateSchedule, stopping at the first error
+func (w *World) RegisterSystems(systems ...System) error {
+	for _, system := range systems {
+		if err := w.RegisterSystem(system); err != nil {
+			return err
+		}
+	}
+	return w.ValidateSchedule()
+}
+
+// DefaultSystems returns a new instance of every system the engine
+// provides, in the order the game registers them
+func DefaultSystems() []System {
+	return []System{
+		&CameraSystem{},
+		&InputSystem{},
+		&AnimationSystem{},
+		&PhysicsSystem{},
+		&PlatformSystem{},
+		&MovementSystem{},
+		&EntityCollisionSystem{},
+		&EntityCollectionSystem{},
+		&AudioSystem{},
+		&HudTextSystem{},
+		&MapRenderSystem{},
+		&RenderSystem{},
+		&WaterRenderSystem{},
+		&TextRenderSystem{},
+	}
+}
+
+func (w *World) getSystem(name string) *scheduledSystem {
+	for _, s := range w.systems {
+		if s.config.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func (w *World) EnableSystem(name string) error {
+	return w.setSystemEnabled(name, true)
+}
+
+func (w *World) DisableSystem(name string) error {
+	return w.setSystemEnabled(name, false)
+}
+
+func (w *World) setSystemEnabled(name string, enabled bool) error {
+	s := w.getSystem(name)
+	if s == nil {
+		return fmt.Errorf("no system named %s", name)
+	}
+	s.enabled = enabled
+	return nil
+}
+
+// ValidateSchedule reports before/after constraints naming systems that
+// were never registered.  RegisterSystem can't tell a typo from a system
+// that hasn't been registered yet, so call this once every system is in.
+func (w *World) ValidateSchedule() error {
+	var unresolved []string
+	for _, s := range w.systems {
+		for _, name := range s.config.Before {
+			if w.getSystem(name) == nil {
+				unresolved = append(unresolved, fmt.Sprintf("%s before %s", s.config.Name, name))
+			}
+		}
+		for _, name := range s.config.After {
+			if w.getSystem(name) == nil {
+				unresolved = append(unresolved, fmt.Sprintf("%s after %s", s.config.Name, name))
+			}
+		}
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("unknown systems in ordering constraints: %s", strings.Join(unresolved, ", "))
+	}
+	return nil
+}
+
+func (w *World) SystemEnabled(name string) bool {
+	s := w.getSystem(name)
+	return s != nil && s.enabled && !w.disabledPhases[s.config.Phase]
+}
+
+// SetPhaseEnabled turns a whole phase on or off, e.g. a pause screen
+// disables simulation while rendering carries on.  Individual systems
+// keep their own enabled flag.
+func (w *World) SetPhaseEnabled(phase SystemPhase, enabled bool) {
+	if phase >= 0 && phase < PHASE_COUNT {
+		w.disabledPhases[phase] = !enabled
+	}
+}
+
+// sortSystems orders systems by phase, then by their before/after
+// constraints, then by registration order
+func sortSystems(systems []*scheduledSystem) ([]*scheduledSystem, error) {
+	byName := make(map[string]*scheduledSystem)
+	for _, s := range systems {
+		byName[s.config.Name] = s
+	}
+
+	// edges[a] holds the systems that must run after a
+	edges := make(map[*scheduledSystem][]*scheduledSystem)
+	incoming := make(map[*scheduledSystem]int)
+	addEdge := func(first, second *scheduledSystem) error {
+		if first.config.Phase != second.config.Phase {
+			return fmt.Errorf(
+				"system %s (%s) can't be ordered against %s (%s), they are in different phases",
+				first.config.Name, first.config.Phase, second.config.Name, second.config.Phase,
+			)
+		}
+		edges[first] = append(edges[first], second)
+		incoming[second]++
+		return nil
+	}
+	for _, s := range systems {
+		for _, name := range s.config.Before {
+			if other, ok := byName[name]; ok {
+				if err := addEdge(s, other); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, name := range s.config.After {
+			if other, ok := byName[name]; ok {
+				if err := addEdge(other, s); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sorted := make([]*scheduledSystem, 0, len(systems))
+	for len(sorted) < len(systems) {
+		// pick the earliest ready system by phase then registration order
+		var next *scheduledSystem
+		for _, s := range systems {
+			if incoming[s] != 0 || containsSystem(sorted, s) {
+				continue
+			}
+			if next == nil || s.config.Phase < next.config.Phase ||
+				(s.config.Phase == next.config.Phase && s.order < next.order) {
+				next = s
+			}
+		}
+		if next == nil {
+			var names []string
+			for _, s := range systems {
+				if !containsSystem(sorted, s) {
+					names = append(names, s.config.Name)
+				}
+			}
+			return nil, fmt.Errorf("system ordering cycle between: %s", strings.Join(names, ", "))
+		}
+		sorted = append(sorted, next)
+		for _, s := range edges[next] {
+			incoming[s]--
+		}
+	}
+	return sorted, nil
+}
+
+func containsSystem(systems []*scheduledSystem, s *scheduledSystem) bool {
+	for _, other := range systems {
+		if other == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package engine
+
+import "testing"
+
+type testSystem struct {
+	config SystemConfig
+}
+
+func (ts *testSystem) Schedule() SystemConfig              { return ts.config }
+func (ts *testSystem) Init(world *World)                   {}
+func (ts *testSystem) Update(engine *Engine, world *World) {}
+
+func TestValidateScheduleReportsUnknownNames(t *testing.T) {
+	w := NewWorld()
+	systems := []*testSystem{
+		{SystemConfig{Name: "physics"}},
+		{SystemConfig{Name: "movement", After: []string{"phyiscs"}}},
+	}
+	for _, s := range systems {
+		if err := w.RegisterSystem(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.ValidateSchedule(); err == nil {
+		t.Fatal("expected an error for a misspelled After constraint")
+	}
+	if err := w.DisableSystem("movment"); err == nil {
+		t.Fatal("expected an error disabling an unknown system")
+	}
+	if err := w.DisableSystem("movement"); err != nil || w.SystemEnabled("movement") {
+		t.Fatalf("movement should be disabled, err: %v", err)
+	}
+}
+
+func TestDefaultSystemsSchedule(t *testing.T) {
+	if err := NewWorld().RegisterSystems(DefaultSystems()...); err != nil {
+		t.Fatal(err)
+	}
+}
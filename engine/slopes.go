@@ -0,0 +1,80 @@
+package engine
+
+import "math"
+
+// slopeHeights holds the height of the floor at the left and right edges
+// of each slope tile, in 16ths of a tile measured up from its bottom
+var slopeHeights = map[int32][2]int32{
+	TILE_TYPE_SLOPE_UP:        {0, 16},
+	TILE_TYPE_SLOPE_DOWN:      {16, 0},
+	TILE_TYPE_SLOPE_UP_LOW:    {0, 8},
+	TILE_TYPE_SLOPE_UP_HIGH:   {8, 16},
+	TILE_TYPE_SLOPE_DOWN_HIGH: {16, 8},
+	TILE_TYPE_SLOPE_DOWN_LOW:  {8, 0},
+}
+
+// Slope tiles aren't solid to SweepBox.  Entities move through them and
+// are then lifted onto the surface under the middle of their bottom edge,
+// the same point the bottom sensor uses.
+
+// SlopeSurface returns the y of the floor surface at pixel column x in
+// the slope tile at column c, row r
+func (m *Map) SlopeSurface(x int32, c, r int32) (float32, bool) {
+	tile := m.tileAt(c, r)
+	if tile == nil {
+		return 0, false
+	}
+	heights, ok := slopeHeights[tile.TypeID]
+	if !ok {
+		return 0, false
+	}
+	ts := float32(m.TileSize)
+	// sample the middle of the pixel so both edges of the tile are reached
+	fx := (float32(x-c*m.TileSize) + 0.5) / ts
+	left := float32(heights[0]) * ts / 16
+	right := float32(heights[1]) * ts / 16
+	return float32((r+1)*m.TileSize) - (left + (right-left)*fx), true
+}
+
+// PointOnSlope reports whether the point is inside a slope tile at or
+// below its surface
+func (m *Map) PointOnSlope(x, y int32) bool {
+	c, r := floorDiv(x, m.TileSize), floorDiv(y, m.TileSize)
+	surface, ok := m.SlopeSurface(x, c, r)
+	return ok && float32(y) >= surface
+}
+
+// GroundSurface finds the highest floor at pixel column x between from
+// and to: a slope surface, or the top of a block or one-way tile.  Block
+// and slope floors above bottom are included so an entity that has sunk
+// into one can be lifted out, one-way tiles only count at or below it.
+func (m *Map) GroundSurface(x int32, bottom, from, to float32, dropThrough bool) (float32, bool) {
+	if m.TileSize <= 0 {
+		return 0, false
+	}
+	c := floorDiv(x, m.TileSize)
+	first := floorDiv(int32(math.Floor(float64(from))), m.TileSize)
+	last := floorDiv(int32(math.Floor(float64(to))), m.TileSize)
+	for r := first; r <= last; r++ {
+		tile := m.tileAt(c, r)
+		if tile == nil {
+			continue
+		}
+		top := float32(r * m.TileSize)
+		var surface float32
+		switch {
+		case tile.IsSlope():
+			surface, _ = m.SlopeSurface(x, c, r)
+		case tile.IsSolid():
+			surface = top
+		case tile.IsOneWay() && !dropThrough && top >= bottom:
+			surface = top
+		default:
+			continue
+		}
+		if surface >= from && surface <= to {
+			return surface, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSlopeSurface(t *testing.T) {
+	// one of each slope in row 1, surfaces are sampled at the middle of
+	// the first and last pixel column of each tile
+	m := newTestMap([]string{
+		"......",
+		`/\abcd`,
+	})
+	cases := []struct {
+		name        string
+		left, right float32
+	}{
+		{"slope up", 31.5, 16.5},
+		{"slope down", 16.5, 31.5},
+		{"slope up low", 31.75, 24.25},
+		{"slope up high", 23.75, 16.25},
+		{"slope down high", 16.25, 23.75},
+		{"slope down low", 24.25, 31.75},
+	}
+	for c, want := range cases {
+		x := int32(c) * 16
+		left, ok := m.SlopeSurface(x, int32(c), 1)
+		right, _ := m.SlopeSurface(x+15, int32(c), 1)
+		if !ok || left != want.left || right != want.right {
+			t.Errorf("%s: got %v to %v, want %v to %v", want.name, left, right, want.left, want.right)
+		}
+	}
+	if _, ok := m.SlopeSurface(8, 0, 0); ok {
+		t.Error("open tile has a slope surface")
+	}
+}
+
+// newSlopeWalker puts an 8x14 entity on the map with its bottom at bottom
+func newSlopeWalker(m *Map, x, bottom float32) (*MovementSystem, *World, *Transform, *State) {
+	world := NewWorld()
+	entity := world.CreateEntity()
+	world.SetMask(entity, COMPONENT_TRANSFORM|COMPONENT_VELOCITY|COMPONENT_STATE)
+	transform := world.GetTransform(entity)
+	transform.W, transform.H = 8, 14
+	transform.X, transform.Y = x, bottom-14
+	ms := &MovementSystem{engine: &Engine{Map: m}, world: world}
+	ms.Update(ms.engine, world)
+	return ms, world, transform, world.GetState(entity)
+}
+
+// walk moves the entity speed pixels a tick for ticks ticks with gravity
+// pulling it down, and fails if it ever leaves the ground or is stopped
+func walk(t *testing.T, m *Map, x, bottom, speed float32, ticks int) *Transform {
+	t.Helper()
+	ms, world, transform, state := newSlopeWalker(m, x, bottom)
+	for i := 0; i < ticks; i++ {
+		// as the input system does
+		state.Grounded = transform.Sensor.Bottom
+		transform.SpeedX = speed
+		transform.SpeedY += GRAVITY
+		lastX := transform.X
+		ms.Update(ms.engine, world)
+		if !transform.Sensor.Bottom {
+			t.Fatalf("left the ground at tick %d, %v,%v", i, transform.X, transform.Y)
+		}
+		if transform.X == lastX {
+			t.Fatalf("stopped at tick %d, %v,%v", i, transform.X, transform.Y)
+		}
+	}
+	return transform
+}
+
+// bottomOn checks the middle of the entity's bottom edge is on the surface
+func bottomOn(t *testing.T, name string, transform *Transform, surface float32) {
+	t.Helper()
+	bottom := transform.Y + float32(transform.H)
+	if math.Abs(float64(bottom-surface)) > 0.01 {
+		t.Errorf("%s: bottom at %v, want %v", name, bottom, surface)
+	}
+}
+
+func TestWalkUpSlopes(t *testing.T) {
+	// floor at y 80, steps up to a ledge at y 32
+	m := newTestMap([]string{
+		"............",
+		"............",
+		"...../######",
+		"..../#######",
+		".../########",
+		"############",
+	})
+	bottomOn(t, "right up 45", walk(t, m, 8, 80, 2, 60), 32)
+
+	m = newTestMap([]string{
+		"..............",
+		"..............",
+		"....ab########",
+		"..ab##########",
+		"##############",
+	})
+	bottomOn(t, "right up 22.5", walk(t, m, 8, 64, 2, 70), 32)
+
+	m = newTestMap([]string{
+		"............",
+		"............",
+		"######\\.....",
+		"#######\\....",
+		"########\\...",
+		"############",
+	})
+	bottomOn(t, "left up 45", walk(t, m, 176, 80, -2, 60), 32)
+}
+
+func TestWalkDownSlopes(t *testing.T) {
+	// without following the ground the entity would skip down the slope
+	// in the air
+	m := newTestMap([]string{
+		"............",
+		"............",
+		"######\\.....",
+		"#######\\....",
+		"########\\...",
+		"############",
+	})
+	bottomOn(t, "right down 45", walk(t, m, 8, 32, 2, 70), 80)
+
+	m = newTestMap([]string{
+		"..............",
+		"..............",
+		"########cd....",
+		"##########cd..",
+		"##############",
+	})
+	bottomOn(t, "right down 22.5", walk(t, m, 8, 32, 2, 95), 64)
+
+	m = newTestMap([]string{
+		"............",
+		"............",
+		"...../######",
+		"..../#######",
+		".../########",
+		"############",
+	})
+	bottomOn(t, "left down 45", walk(t, m, 176, 32, -2, 75), 80)
+}
+
+func TestSnapDownHalfTile(t *testing.T) {
+	// the slope surface under x 24 is at y 40.5
+	m := newTestMap([]string{
+		"...",
+		"...",
+		`.\.`,
+		"###",
+	})
+	cases := []struct {
+		name     string
+		bottom   float32
+		grounded bool
+		want     float32
+	}{
+		{"grounded within half a tile", 34.5, true, 40.5},
+		{"grounded beyond half a tile", 30.5, true, 30.5},
+		{"in the air", 34.5, false, 34.5},
+	}
+	for _, c := range cases {
+		ms, _, transform, state := newSlopeWalker(m, 20, c.bottom)
+		state.Grounded = c.grounded
+		ms.transform, ms.stateCmp = transform, state
+		ms.Move(0, 0)
+		bottomOn(t, c.name, transform, c.want)
+	}
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// bump whenever the snapshot layout changes in a way older
+// loaders can't read, and add a step to migrateSnapshot
+//
+//	2: Tag's single Value became Values and Groups
+//	3: MaxSpeedX and MaxSpeedY moved from Transform to PhysicsBody
+const SNAPSHOT_VERSION = 3
+
+type worldSnapshot struct {
+	Version     int              `json:"version"`
+	Generations []uint32         `json:"generations"`
+	Entities    []entitySnapshot `json:"entities"`
+}
+
+// components are keyed by their registered name rather than mask bit
+// since bits for game components depend on registration order
+type entitySnapshot struct {
+	Index      int                        `json:"index"`
+	Generation uint32                     `json:"generation"`
+	Components map[string]json.RawMessage `json:"components"`
+}
+
+// Save writes every live entity and its components to writer.  Slot
+// indices and generations are preserved so entity references stored in
+// components (Parent, events in flight, etc) survive a round trip.
+func (w *World) Save(writer io.Writer) error {
+	snapshot := worldSnapshot{
+		Version:     SNAPSHOT_VERSION,
+		Generations: w.generations,
+	}
+
+	for index, mask := range w.mask {
+		entity := w.EntityAt(index)
+		if entity == ENTITY_NONE {
+			continue
+		}
+		es := entitySnapshot{
+			Index:      index,
+			Generation: entity.Generation(),
+			Components: make(map[string]json.RawMessage),
+		}
+		for id, info := range components {
+			if !signatureMatches(mask, info.bit) {
+				continue
+			}
+			data, err := w.store(id).marshal(index)
+			if err != nil {
+				return fmt.Errorf("entity %d component %s: %s", index, info.name, err)
+			}
+			es.Components[info.name] = data
+		}
+		snapshot.Entities = append(snapshot.Entities, es)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// Load restores a snapshot written by Save.  It must be called on a fresh
+// world with no live entities.
+func (w *World) Load(reader io.Reader) error {
+	for _, alive := range w.alive {
+		if alive {
+			return errors.New("Load requires a world with no live entities")
+		}
+	}
+
+	var snapshot worldSnapshot
+	if err := json.NewDecoder(reader).Decode(&snapshot); err != nil {
+		return err
+	}
+	if snapshot.Version < 1 || snapshot.Version > SNAPSHOT_VERSION {
+		return fmt.Errorf("unsupported snapshot version: %d", snapshot.Version)
+	}
+	if err := migrateSnapshot(&snapshot); err != nil {
+		return fmt.Errorf("migrating snapshot version %d: %s", snapshot.Version, err)
+	}
+
+	if n := len(snapshot.Generations) - len(w.mask); n > 0 {
+		w.grow(n)
+	}
+	copy(w.generations, snapshot.Generations)
+
+	for _, es := range snapshot.Entities {
+		if es.Index < 0 || es.Index >= len(w.mask) {
+			return fmt.Errorf("entity index out of range: %d", es.Index)
+		}
+		w.generations[es.Index] = es.Generation
+		w.alive[es.Index] = true
+		w.clear(es.Index)
+		entity := NewEntity(es.Index, es.Generation)
+		w.Events.EmitEvent(&EntityCreatedEvent{Entity: entity})
+
+		var mask uint64
+		for name, data := range es.Components {
+			id, ok := componentByName(name)
+			if !ok {
+				return fmt.Errorf("entity %d has unregistered component: %s", es.Index, name)
+			}
+			if err := w.store(id).unmarshal(es.Index, data); err != nil {
+				return fmt.Errorf("entity %d component %s: %s", es.Index, name, err)
+			}
+			mask |= components[id].bit
+		}
+		w.SetMask(entity, mask)
+	}
+
+	// rebuild the free list from whatever slots the snapshot left empty
+	w.freeList = w.freeList[:0]
+	w.pendingFree = w.pendingFree[:0]
+	for index := len(w.alive) - 1; index >= 0; index-- {
+		if !w.alive[index] {
+			w.freeList = append(w.freeList, index)
+		}
+	}
+	return nil
+}
+
+// migrateSnapshot rewrites components saved by older versions into the
+// current layout, one version at a time
+func migrateSnapshot(snapshot *worldSnapshot) error {
+	steps := map[int]func(entitySnapshot) error{
+		1: migrateEntityV1,
+		2: migrateEntityV2,
+	}
+	for ; snapshot.Version < SNAPSHOT_VERSION; snapshot.Version++ {
+		for _, es := range snapshot.Entities {
+			if err := steps[snapshot.Version](es); err != nil {
+				return fmt.Errorf("entity %d: %s", es.Index, err)
+			}
+		}
+	}
+	return nil
+}
+
+// migrateEntityV1 turns a Tag's single Value into Values
+func migrateEntityV1(es entitySnapshot) error {
+	data, ok := es.Components["tag"]
+	if !ok {
+		return nil
+	}
+	var old struct{ Value string }
+	if err := json.Unmarshal(data, &old); err != nil {
+		return fmt.Errorf("tag: %s", err)
+	}
+	var tag Tag
+	if old.Value != "" {
+		tag.Values = []string{old.Value}
+	}
+	migrated, err := json.Marshal(tag)
+	if err != nil {
+		return err
+	}
+	es.Components["tag"] = migrated
+	return nil
+}
+
+// migrateEntityV2 moves max speeds from the transform to a physics body
+func migrateEntityV2(es entitySnapshot) error {
+	data, ok := es.Components["transform"]
+	if !ok {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("transform: %s", err)
+	}
+	speeds := make(map[string]json.RawMessage)
+	for _, name := range []string{"MaxSpeedX", "MaxSpeedY"} {
+		if value, ok := fields[name]; ok {
+			speeds[name] = value
+			delete(fields, name)
+		}
+	}
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	es.Components["transform"] = migrated
+
+	// physics used to run on controlled entities only, anything else
+	// getting a body now would start falling
+	_, controlled := es.Components["controller"]
+	_, hasBody := es.Components["physics"]
+	if controlled && !hasBody && len(speeds) > 0 {
+		body, err := json.Marshal(speeds)
+		if err != nil {
+			return err
+		}
+		es.Components["physics"] = body
+	}
+	return nil
+}
+
+func (w *World) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return w.Save(f)
+}
+
+func (w *World) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return w.Load(f)
+}
@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	w := NewWorld()
+
+	player := w.CreateEntity()
+	w.SetMask(player, COMPONENT_TRANSFORM|COMPONENT_STATE|COMPONENT_ANIMATION|COMPONENT_INVENTORY)
+	*w.GetTransform(player) = Transform{X: 12, Y: 34, W: 16, H: 24, SpeedX: 1.5}
+	*w.GetState(player) = State{Grounded: true, MoveRight: true, JumpCount: 1, State: ENTITY_STATE_CLIMB}
+	*w.GetAnimation(player) = Animation{
+		AnimationStates: map[StateKey]AnimationState{
+			ENTITY_STATE_LEFT: {Asset: "Player/Run", FrameRate: 100, Infinite: true},
+		},
+		UnderwaterStates: map[StateKey]AnimationState{
+			ENTITY_STATE_LEFT: {Asset: "Player/Underwater/Swim"},
+		},
+		AnimState:    ENTITY_STATE_LEFT,
+		CurrentFrame: 2,
+	}
+	w.GetInventory(player).Items = map[string]int{"gold": 3}
+	w.AddTag(player, "player")
+	w.AddTag(player, "hero")
+	w.AddToGroup(player, "friendly")
+
+	// leave a hole in the slots so the free list has to be rebuilt
+	dead := w.CreateEntity()
+	w.DestroyEntity(dead)
+
+	child := w.CreateEntity()
+	w.SetMask(child, COMPONENT_TRANSFORM)
+	w.GetTransform(child).X = 4
+	w.SetParent(child, player)
+	w.Update(nil)
+
+	var buf bytes.Buffer
+	if err := w.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewWorld()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !loaded.Alive(player) || !loaded.Alive(child) || loaded.Alive(dead) {
+		t.Fatal("entity handles did not survive the round trip")
+	}
+	for _, entity := range []Entity{player, child} {
+		if loaded.GetMask(entity) != w.GetMask(entity) {
+			t.Errorf("entity %d mask: got %b, want %b", entity.Index(), loaded.GetMask(entity), w.GetMask(entity))
+		}
+		if !reflect.DeepEqual(loaded.GetTransform(entity), w.GetTransform(entity)) {
+			t.Errorf("entity %d transform: got %+v, want %+v", entity.Index(), loaded.GetTransform(entity), w.GetTransform(entity))
+		}
+	}
+	if !reflect.DeepEqual(loaded.GetState(player), w.GetState(player)) {
+		t.Errorf("state: got %+v, want %+v", loaded.GetState(player), w.GetState(player))
+	}
+	if !reflect.DeepEqual(loaded.GetAnimation(player), w.GetAnimation(player)) {
+		t.Errorf("animation: got %+v, want %+v", loaded.GetAnimation(player), w.GetAnimation(player))
+	}
+	if !reflect.DeepEqual(loaded.GetInventory(player), w.GetInventory(player)) {
+		t.Errorf("inventory: got %+v, want %+v", loaded.GetInventory(player), w.GetInventory(player))
+	}
+	if !reflect.DeepEqual(loaded.GetTag(player), w.GetTag(player)) {
+		t.Errorf("tag: got %+v, want %+v", loaded.GetTag(player), w.GetTag(player))
+	}
+	if loaded.FindByTag("hero") != player || !loaded.InGroup(player, "friendly") {
+		t.Error("tag index not rebuilt on load")
+	}
+	if loaded.GetParent(child) != player {
+		t.Errorf("parent: got %v, want %v", loaded.GetParent(child), player)
+	}
+
+	if reused := loaded.CreateEntity(); reused.Index() != dead.Index() || reused == dead {
+		t.Errorf("free slot not reused: got %v after %v", reused, dead)
+	}
+}
+
+func TestSnapshotRejectsUnknownFields(t *testing.T) {
+	snapshot := fmt.Sprintf(`{
+		"version": %d,
+		"generations": [1],
+		"entities": [
+			{"index": 0, "generation": 1, "components": {"inventory": {"Itmes": {"gold": 1}}}}
+		]
+	}`, SNAPSHOT_VERSION)
+	if err := NewWorld().Load(strings.NewReader(snapshot)); err == nil {
+		t.Fatal("expected an error for an unknown component field")
+	}
+}
+
+func TestSnapshotMigratesVersion1(t *testing.T) {
+	snapshot := `{
+		"version": 1,
+		"generations": [1, 1],
+		"entities": [
+			{"index": 0, "generation": 1, "components": {
+				"tag": {"Value": "player"},
+				"state": {"Grounded": true, "State": 1}
+			}},
+			{"index": 1, "generation": 1, "components": {
+				"tag": {"Value": ""}
+			}}
+		]
+	}`
+	w := NewWorld()
+	if err := w.Load(strings.NewReader(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+
+	player := w.FindByTag("player")
+	if player == ENTITY_NONE {
+		t.Fatal("version 1 tag was dropped")
+	}
+	if tag := w.GetTag(player); len(tag.Values) != 1 || len(tag.Groups) != 0 {
+		t.Errorf("tag: got %+v, want Values [player]", tag)
+	}
+	if tag := w.GetTag(w.EntityAt(1)); len(tag.Values) != 0 {
+		t.Errorf("empty tag value became %+v", tag)
+	}
+	if state := w.GetState(player).State; state != StateKey(1) {
+		t.Errorf("numeric state: got %v, want 1", state)
+	}
+}
+
+func TestSnapshotMigratesVersion2(t *testing.T) {
+	snapshot := `{
+		"version": 2,
+		"generations": [1, 1],
+		"entities": [
+			{"index": 0, "generation": 1, "components": {
+				"transform": {"X": 10, "W": 9, "H": 14, "MaxSpeedX": 3, "MaxSpeedY": 6},
+				"controller": {}
+			}},
+			{"index": 1, "generation": 1, "components": {
+				"transform": {"X": 20, "MaxSpeedX": 3, "MaxSpeedY": 6}
+			}}
+		]
+	}`
+	w := NewWorld()
+	if err := w.Load(strings.NewReader(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+
+	player := w.EntityAt(0)
+	if !w.HasComponents(player, COMPONENT_PHYSICS_BODY) {
+		t.Fatal("controlled entity's max speeds weren't moved to a physics body")
+	}
+	want := DefaultPhysicsBody
+	want.MaxSpeedX, want.MaxSpeedY = 3, 6
+	if body := *w.GetPhysicsBody(player); body != want {
+		t.Errorf("body: got %+v, want %+v", body, want)
+	}
+	if transform := w.GetTransform(player); transform.X != 10 || transform.W != 9 {
+		t.Errorf("transform: got %+v", transform)
+	}
+
+	other := w.EntityAt(1)
+	if w.HasComponents(other, COMPONENT_PHYSICS_BODY) {
+		t.Error("uncontrolled entity was given a physics body")
+	}
+	if transform := w.GetTransform(other); transform.X != 20 {
+		t.Errorf("transform: got %+v", transform)
+	}
+}
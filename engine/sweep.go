@@ -0,0 +1,195 @@
+package engine
+
+import "math"
+
+// TileHit describes the first solid tile a moving box runs into
+type TileHit struct {
+	Hit bool
+	// fraction of the move made before touching the tile, 0 to 1
+	Time float32
+	// points away from the surface that was hit, only one is non-zero
+	NormalX int32
+	NormalY int32
+	// where the box ends up, touching the tile if Hit is set
+	X float32
+	Y float32
+}
+
+// SweepBox moves a w by h box at x, y by dx, dy and returns where it first
+// touches a solid tile.  Every tile column and row the box's leading edges
+// cross is checked, so fast moves can't skip through thin walls, and the
+// cost depends on the number of tiles crossed rather than pixels.
+//
+// Positions are continuous.  A box exactly touching a tile can't move
+// into it, and a box overlapping a row or column by any fraction of a
+// pixel is blocked by it.  A box that reaches the corner of a tile on
+// both axes at once lands on it, hits on rows win ties.
+//
+// One-way tiles only block a box moving down into them from above, and
+// not at all when dropThrough is set.
+func (m *Map) SweepBox(x, y float32, w, h int32, dx, dy float32, dropThrough bool) TileHit {
+	result := TileHit{Time: 1, X: x + dx, Y: y + dy}
+	if m.TileSize <= 0 || (dx == 0 && dy == 0) {
+		return result
+	}
+
+	if t, c, ok := m.sweepColumns(x, y, w, h, dx, dy); ok {
+		result = TileHit{Hit: true, Time: t, Y: y + dy*t}
+		if dx > 0 {
+			result.NormalX = -1
+		} else {
+			result.NormalX = 1
+		}
+		result.X = m.contactPosition(x, w, dx, c, t)
+	}
+	if t, r, ok := m.sweepRows(x, y, w, h, dx, dy, dropThrough); ok && (!result.Hit || t <= result.Time) {
+		result = TileHit{Hit: true, Time: t, X: x + dx*t}
+		if dy > 0 {
+			result.NormalY = -1
+		} else {
+			result.NormalY = 1
+		}
+		result.Y = m.contactPosition(y, h, dy, r, t)
+	}
+	return result
+}
+
+// sweepColumns finds the first solid column the box's leading x edge
+// enters and the time it touches it
+func (m *Map) sweepColumns(x, y float32, w, h int32, dx, dy float32) (float32, int32, bool) {
+	if dx == 0 {
+		return 0, 0, false
+	}
+	for _, c := range m.crossedTiles(x, w, dx) {
+		t := m.contactTime(x, w, dx, c)
+		top, bottom := m.coveredTiles(y+dy*t, h, dy)
+		for r := top; r <= bottom; r++ {
+			if m.solidTile(c, r) {
+				return t, c, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// sweepRows is sweepColumns for the y edge.  Rows are only entered from
+// the side the box is moving from, so a one-way row entered moving down
+// was landed on from above.
+func (m *Map) sweepRows(x, y float32, w, h int32, dx, dy float32, dropThrough bool) (float32, int32, bool) {
+	if dy == 0 {
+		return 0, 0, false
+	}
+	oneWay := dy > 0 && !dropThrough
+	for _, r := range m.crossedTiles(y, h, dy) {
+		t := m.contactTime(y, h, dy, r)
+		left, right := m.coveredTiles(x+dx*t, w, dx)
+		for c := left; c <= right; c++ {
+			if m.solidTile(c, r) || (oneWay && m.oneWayTile(c, r)) {
+				return t, r, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// crossedTiles lists, in the order they are reached, the tiles on one
+// axis that the leading edge of a span starting at pos and size long
+// enters when it moves by delta.  Tiles the span already overlaps aren't
+// included.
+func (m *Map) crossedTiles(pos float32, size int32, delta float32) []int32 {
+	ts := float64(m.TileSize)
+	var tiles []int32
+	if delta > 0 {
+		edge := float64(pos) + float64(size)
+		first := int32(math.Ceil(edge / ts))
+		last := int32(math.Ceil((edge+float64(delta))/ts)) - 1
+		for i := first; i <= last; i++ {
+			tiles = append(tiles, i)
+		}
+	} else {
+		first := int32(math.Floor(float64(pos)/ts)) - 1
+		last := int32(math.Floor(float64(pos+delta) / ts))
+		for i := first; i >= last; i-- {
+			tiles = append(tiles, i)
+		}
+	}
+	return tiles
+}
+
+// nearBoundary is where a span moving by delta stops when it touches tile i
+func (m *Map) nearBoundary(size int32, delta float32, i int32) float32 {
+	if delta > 0 {
+		return float32(i*m.TileSize - size)
+	}
+	return float32((i + 1) * m.TileSize)
+}
+
+// contactTime is when the leading edge reaches tile i
+func (m *Map) contactTime(pos float32, size int32, delta float32, i int32) float32 {
+	t := (m.nearBoundary(size, delta, i) - pos) / delta
+	// a box already overlapping the tile stops where it is
+	if t < 0 {
+		t = 0
+	}
+	return t
+}
+
+// contactPosition is where a span stops after being blocked by tile i,
+// exactly on the boundary rather than pos+delta*t to avoid rounding
+func (m *Map) contactPosition(pos float32, size int32, delta float32, i int32, t float32) float32 {
+	if t == 0 {
+		return pos
+	}
+	return m.nearBoundary(size, delta, i)
+}
+
+// coveredTiles is the range of tiles a span starting at pos and size
+// long overlaps.  A tile the span only touches the edge of is included
+// when the span is moving into it by delta, otherwise a box moving
+// diagonally exactly onto a corner would pass into the tile.
+func (m *Map) coveredTiles(pos float32, size int32, delta float32) (int32, int32) {
+	ts := float64(m.TileSize)
+	start := float64(pos)
+	end := start + float64(size)
+	first := int32(math.Floor(start / ts))
+	last := int32(math.Ceil(end/ts)) - 1
+	if delta < 0 && math.Mod(start, ts) == 0 {
+		first--
+	}
+	if delta > 0 && math.Mod(end, ts) == 0 {
+		last++
+	}
+	return first, last
+}
+
+// tileAt returns the tile at column c, row r or nil outside the map
+func (m *Map) tileAt(c, r int32) *Tile {
+	if c < 0 || r < 0 || c >= m.Width || r >= m.Height {
+		return nil
+	}
+	id := r*m.Width + c
+	if id >= int32(len(m.tileList)) {
+		return nil
+	}
+	return &m.tileList[id]
+}
+
+// solidTile reports whether the tile at column c, row r blocks movement.
+// Anything outside the map is open.
+func (m *Map) solidTile(c, r int32) bool {
+	tile := m.tileAt(c, r)
+	return tile != nil && tile.IsSolid()
+}
+
+func (m *Map) oneWayTile(c, r int32) bool {
+	tile := m.tileAt(c, r)
+	return tile != nil && tile.IsOneWay()
+}
+
+func floorDiv(a, b int32) int32 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
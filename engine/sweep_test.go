@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+// newTestMap builds a 16 pixel tile map from rows of text, '#' is a block,
+// '=' a one-way tile, '/' and '\' 45 degree slopes, 'a' 'b' the low and
+// high halves of a 22.5 degree slope up, 'c' 'd' the high and low halves
+// of one down, and anything else open
+func newTestMap(rows []string) *Map {
+	m := &Map{TileSize: 16, Width: int32(len(rows[0])), Height: int32(len(rows))}
+	for _, row := range rows {
+		for _, c := range row {
+			var tile Tile
+			switch c {
+			case '#':
+				tile.TypeID = TILE_TYPE_BLOCK
+			case '=':
+				tile.TypeID = TILE_TYPE_ONEWAY
+			case '/':
+				tile.TypeID = TILE_TYPE_SLOPE_UP
+			case '\\':
+				tile.TypeID = TILE_TYPE_SLOPE_DOWN
+			case 'a':
+				tile.TypeID = TILE_TYPE_SLOPE_UP_LOW
+			case 'b':
+				tile.TypeID = TILE_TYPE_SLOPE_UP_HIGH
+			case 'c':
+				tile.TypeID = TILE_TYPE_SLOPE_DOWN_HIGH
+			case 'd':
+				tile.TypeID = TILE_TYPE_SLOPE_DOWN_LOW
+			}
+			m.tileList = append(m.tileList, tile)
+		}
+	}
+	return m
+}
+
+type sweepCase struct {
+	name        string
+	x, y        float32
+	w, h        int32
+	dx, dy      float32
+	dropThrough bool
+	want        TileHit
+}
+
+func runSweepCases(t *testing.T, m *Map, cases []sweepCase) {
+	t.Helper()
+	near := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-4 }
+	for _, c := range cases {
+		got := m.SweepBox(c.x, c.y, c.w, c.h, c.dx, c.dy, c.dropThrough)
+		if got.Hit != c.want.Hit || got.NormalX != c.want.NormalX || got.NormalY != c.want.NormalY ||
+			!near(got.Time, c.want.Time) || !near(got.X, c.want.X) || !near(got.Y, c.want.Y) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSweepBox(t *testing.T) {
+	// blocks at x 64-80 in rows 1 and 3, floor from y 80
+	m := newTestMap([]string{
+		"..........",
+		"....#.....",
+		"..........",
+		"....#.....",
+		"..........",
+		"##########",
+	})
+	runSweepCases(t, m, []sweepCase{
+		{
+			name: "open move",
+			x: 10, y: 34, w: 8, h: 8, dx: 20, dy: 2,
+			want: TileHit{Time: 1, X: 30, Y: 36},
+		},
+		{
+			name: "half a pixel into the row below is blocked",
+			x: 40, y: 32.5, w: 8, h: 16, dx: 20,
+			want: TileHit{Hit: true, Time: 0.8, NormalX: -1, X: 56, Y: 32.5},
+		},
+		{
+			name: "touching the row below isn't blocked",
+			x: 40, y: 32, w: 8, h: 16, dx: 20,
+			want: TileHit{Time: 1, X: 60, Y: 32},
+		},
+		{
+			name: "fractional start",
+			x: 10.5, y: 18, w: 9, h: 10, dx: 60,
+			want: TileHit{Hit: true, Time: 44.5 / 60.0, NormalX: -1, X: 55, Y: 18},
+		},
+		{
+			name: "left from a boundary against a wall",
+			x: 80, y: 18, w: 8, h: 8, dx: -4,
+			want: TileHit{Hit: true, Time: 0, NormalX: 1, X: 80, Y: 18},
+		},
+		{
+			name: "left from a boundary into open tiles",
+			x: 96, y: 34, w: 8, h: 8, dx: -16,
+			want: TileHit{Time: 1, X: 80, Y: 34},
+		},
+		{
+			name: "up from a boundary against a ceiling",
+			x: 66, y: 32, w: 8, h: 8, dy: -4,
+			want: TileHit{Hit: true, Time: 0, NormalY: 1, X: 66, Y: 32},
+		},
+		{
+			name: "long move right into a one tile wall",
+			x: 0, y: 18, w: 8, h: 8, dx: 500,
+			want: TileHit{Hit: true, Time: 56 / 500.0, NormalX: -1, X: 56, Y: 18},
+		},
+		{
+			name: "long fall onto the floor",
+			x: 20, y: 0, w: 8, h: 8, dy: 500,
+			want: TileHit{Hit: true, Time: 72 / 500.0, NormalY: -1, X: 20, Y: 72},
+		},
+		{
+			name: "diagonal onto a corner lands on it",
+			x: 48, y: 0, w: 8, h: 8, dx: 16, dy: 16,
+			want: TileHit{Hit: true, Time: 0.5, NormalY: -1, X: 56, Y: 8},
+		},
+		{
+			name: "diagonal into the side of a block",
+			x: 40, y: 4, w: 8, h: 8, dx: 20, dy: 10,
+			want: TileHit{Hit: true, Time: 0.8, NormalX: -1, X: 56, Y: 12},
+		},
+		{
+			name: "diagonal onto the top of a block",
+			x: 60, y: 0, w: 8, h: 8, dx: 4, dy: 16,
+			want: TileHit{Hit: true, Time: 0.5, NormalY: -1, X: 62, Y: 8},
+		},
+	})
+}
+
+func TestSweepBoxOneWay(t *testing.T) {
+	// one-way row from y 32 to 48
+	m := newTestMap([]string{
+		"....",
+		"....",
+		"====",
+		"....",
+	})
+	runSweepCases(t, m, []sweepCase{
+		{
+			name: "landing from above",
+			x: 10, y: 0, w: 9, h: 14, dy: 40,
+			want: TileHit{Hit: true, Time: 18 / 40.0, NormalY: -1, X: 10, Y: 18},
+		},
+		{
+			name: "dropping through",
+			x: 10, y: 0, w: 9, h: 14, dy: 40, dropThrough: true,
+			want: TileHit{Time: 1, X: 10, Y: 40},
+		},
+		{
+			name: "resting on top",
+			x: 10, y: 18, w: 9, h: 14, dy: 0.13,
+			want: TileHit{Hit: true, Time: 0, NormalY: -1, X: 10, Y: 18},
+		},
+		{
+			name: "dropping from resting on top",
+			x: 10, y: 18, w: 9, h: 14, dy: 0.13, dropThrough: true,
+			want: TileHit{Time: 1, X: 10, Y: 18.13},
+		},
+		{
+			name: "already inside moving down",
+			x: 10, y: 18.5, w: 9, h: 14, dy: 5,
+			want: TileHit{Time: 1, X: 10, Y: 23.5},
+		},
+		{
+			name: "jumping up through",
+			x: 10, y: 50, w: 9, h: 14, dy: -40,
+			want: TileHit{Time: 1, X: 10, Y: 10},
+		},
+		{
+			name: "moving sideways inside",
+			x: 0, y: 36, w: 9, h: 8, dx: 40,
+			want: TileHit{Time: 1, X: 40, Y: 36},
+		},
+	})
+}
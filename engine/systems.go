@@ -1,9 +1,8 @@
 package engine
 
 import (
-	"fmt"
 	"github.com/veandco/go-sdl2/sdl"
-	"os"
+	"sort"
 	"strconv"
 )
 
@@ -34,57 +33,73 @@ func signatureMatches(mask, signature uint64) bool {
 type RenderSystem struct {
 	SystemEvents
 }
+func (rs *RenderSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "render",
+		Phase: PHASE_RENDER,
+		After: []string{"map-render"},
+	}
+}
 func (rs *RenderSystem) Init(world *World) {
 }
 func (rs *RenderSystem) Update (engine *Engine, world *World) {
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_TRANSFORM|COMPONENT_ANIMATION) {
-			transformCmp := world.GetTransform(entity)
-			animationCmp := world.GetAnimation(entity)
-			stateCmp := world.GetState(entity)
-
-			// grab Animation metadata
-			animState := animationCmp.AnimationStates[animationCmp.AnimState]
-			// grab frames
-			frames := engine.Assets.Get(animState.Asset)
-			frame := frames[animationCmp.CurrentFrame]
-
-			// perform sprite Flip if needed
-			flip := sdl.FLIP_NONE
-			if signatureMatches(mask, COMPONENT_STATE) {
-				if stateCmp.Orientation != animState.Orientation {
-					flip = sdl.FLIP_HORIZONTAL
-				} else {
-					flip = sdl.FLIP_NONE
-				}
+	for _, entity := range world.Query(COMPONENT_TRANSFORM|COMPONENT_ANIMATION) {
+		mask := world.GetMask(entity)
+		transformCmp := world.GetTransform(entity)
+		animationCmp := world.GetAnimation(entity)
+		stateCmp := world.GetState(entity)
+
+		// grab Animation metadata
+		animState := animationCmp.CurrentState()
+		// grab frames
+		frames := engine.Assets.Get(animState.Asset)
+		if animationCmp.CurrentFrame >= len(frames) {
+			continue
+		}
+		frame := frames[animationCmp.CurrentFrame]
+
+		// perform sprite Flip if needed
+		flip := sdl.FLIP_NONE
+		if signatureMatches(mask, COMPONENT_STATE) {
+			if stateCmp.Orientation != animState.Orientation {
+				flip = sdl.FLIP_HORIZONTAL
+			} else {
+				flip = sdl.FLIP_NONE
 			}
+		}
 
-			// determine X and Y
-			x := int32(transformCmp.X - engine.Camera.X())
-			y := int32(transformCmp.Y - engine.Camera.Y())
+		// determine X and Y, children are positioned relative to their parent
+		worldX, worldY := world.RenderPosition(entity, engine.Interpolation())
+		x := int32(worldX - engine.Camera.X())
+		y := int32(worldY - engine.Camera.Y())
 
-			if signatureMatches(mask, COMPONENT_HUD) {
-				x = int32(transformCmp.X)
-				y = int32(transformCmp.Y)
-			}
+		if signatureMatches(mask, COMPONENT_HUD) {
+			x = int32(worldX)
+			y = int32(worldY)
+		}
 
-			// line up bounding box center with actual sprite center
-			offsetW := (frame.W / 2) - (transformCmp.W / 2)
-			offsetH := (frame.H / 2) - (transformCmp.H / 2)
-			offsetX := x - offsetW
-			offsetY := y - offsetH
+		// line up bounding box center with actual sprite center
+		offsetW := (frame.W / 2) - (transformCmp.W / 2)
+		offsetH := (frame.H / 2) - (transformCmp.H / 2)
+		offsetX := x - offsetW
+		offsetY := y - offsetH
 
-			if engine.Config.DrawDebug {
-				engine.Graphics.DrawRectOutline(x, y, transformCmp.W, transformCmp.H)
-			}
-			engine.Graphics.DrawPart(engine.Assets.Texture, offsetX, offsetY, frame.X, frame.Y, frame.W, frame.H, flip)
+		if engine.Config.DrawDebug {
+			engine.Graphics.DrawRectOutline(x, y, transformCmp.W, transformCmp.H)
 		}
+		engine.Graphics.DrawPart(engine.Assets.Texture, offsetX, offsetY, frame.X, frame.Y, frame.W, frame.H, flip)
 	}
 }
 
 type MapRenderSystem struct {
 	SystemEvents
 }
+func (mrs *MapRenderSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "map-render",
+		Phase: PHASE_RENDER,
+	}
+}
 func (mrs *MapRenderSystem) Init(world *World) {
 
 }
@@ -95,39 +110,56 @@ func (mrs *MapRenderSystem) Update (engine *Engine, world *World) {
 type AnimationSystem struct {
 	SystemEvents
 }
+func (as *AnimationSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "animation",
+		Phase: PHASE_POST_SIMULATION,
+	}
+}
 func (as *AnimationSystem) Init(world *World) {
 
 }
 func (as *AnimationSystem) Update(engine *Engine, world *World) {
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_ANIMATION|COMPONENT_STATE) {
-			animationCmp := world.GetAnimation(entity)
-			stateCmp := world.GetState(entity)
-
-			// determine if we are transitioning to a new State
-			// or keeping the current State.  Transitioning to
-			// a new State should reset the current frame to 0
-			if stateCmp.State != animationCmp.AnimState {
+	for _, entity := range world.Query(COMPONENT_ANIMATION|COMPONENT_STATE) {
+		animationCmp := world.GetAnimation(entity)
+		stateCmp := world.GetState(entity)
+
+		// determine if we are transitioning to a new State
+		// or keeping the current State.  Transitioning to
+		// a new State should reset the current frame to 0
+		if stateCmp.State != animationCmp.AnimState {
+			animationCmp.CurrentFrame = 0
+			animationCmp.AnimState = stateCmp.State
+		}
+		// so does switching to or from the underwater animations
+		if world.HasComponents(entity, COMPONENT_TRANSFORM) {
+			underwater := world.GetTransform(entity).Sensor.Water != ENTITY_NONE
+			if underwater != animationCmp.Underwater {
 				animationCmp.CurrentFrame = 0
-				animationCmp.AnimState = stateCmp.State
+				animationCmp.Underwater = underwater
 			}
+		}
 
-			animState := animationCmp.CurrentState()
-			frames := engine.Assets.Get(animState.Asset)
-			animationCmp.MaxFrames = len(frames)
-			animationCmp.FrameInc = 1
+		animState := animationCmp.CurrentState()
+		frames := engine.Assets.Get(animState.Asset)
+		animationCmp.MaxFrames = len(frames)
+		animationCmp.FrameInc = 1
 
-			// advance frames
-			currentTime := sdl.GetTicks()
-			threshold := animationCmp.OldTime + uint32(animState.FrameRate)
-			if threshold > currentTime {
-				continue
-			}
-			animationCmp.OldTime = currentTime
-			animationCmp.CurrentFrame += animationCmp.FrameInc
-			if animationCmp.CurrentFrame >= animationCmp.MaxFrames {
-				animationCmp.CurrentFrame = 0
-			}
+		// hold still on a ladder unless climbing
+		if stateCmp.Climbing && !stateCmp.MoveUp && !stateCmp.MoveDown {
+			continue
+		}
+
+		// advance frames on the game clock so animations pause with the game
+		currentTime := engine.GameTime()
+		threshold := animationCmp.OldTime + uint32(animState.FrameRate)
+		if threshold > currentTime {
+			continue
+		}
+		animationCmp.OldTime = currentTime
+		animationCmp.CurrentFrame += animationCmp.FrameInc
+		if animationCmp.CurrentFrame >= animationCmp.MaxFrames {
+			animationCmp.CurrentFrame = 0
 		}
 	}
 }
@@ -135,191 +167,327 @@ func (as *AnimationSystem) Update(engine *Engine, world *World) {
 type InputSystem struct {
 	SystemEvents
 }
+func (is *InputSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "input",
+		Phase: PHASE_INPUT,
+	}
+}
 func (is *InputSystem) Init(world *World) {}
 func (is *InputSystem) Update(engine *Engine, world *World) {
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_STATE|COMPONENT_CONTROLLER|COMPONENT_TRANSFORM) {
-			s := world.GetState(entity)
-			transform := world.GetTransform(entity)
-
-			// s.MoveRight = engine.Input.KeysHeld[sdl.K_RIGHT] || engine.Input.KeysHeld[sdl.K_d]
-			s.MoveRight = engine.Input.KeysHeld[sdl.K_RIGHT] && !s.Sliding
-			s.MoveLeft = engine.Input.KeysHeld[sdl.K_LEFT] && !s.Sliding
-			s.Rolling = engine.Input.KeysHeld[sdl.K_DOWN] && s.Grounded
-			s.Shooting = engine.Input.KeysHeld[sdl.K_RSHIFT]
-
-			s.Grounded = transform.Sensor.Bottom
-			s.LeftSlide = transform.Sensor.Left
-			s.RightSlide = transform.Sensor.Right
-			s.Sliding = (s.LeftSlide || s.RightSlide) && !s.Grounded
-
-			// s.Jumping = !s.Grounded
-
-			if s.Grounded {
-				s.Jumping = false
-				s.JumpCount = 0
-				s.JumpFrameCount = 0
+	// held keys are picked up again when the game is unpaused
+	if engine.Paused() {
+		return
+	}
+	for _, entity := range world.Query(COMPONENT_STATE|COMPONENT_CONTROLLER|COMPONENT_TRANSFORM) {
+		s := world.GetState(entity)
+		transform := world.GetTransform(entity)
+		body := world.GetPhysicsBody(entity)
+
+		// s.MoveRight = engine.Input.KeysHeld[sdl.K_RIGHT] || engine.Input.KeysHeld[sdl.K_d]
+		s.MoveRight = engine.Input.KeysHeld[sdl.K_RIGHT] && !s.Sliding
+		s.MoveLeft = engine.Input.KeysHeld[sdl.K_LEFT] && !s.Sliding
+		s.MoveUp = engine.Input.KeysHeld[sdl.K_UP]
+		s.MoveDown = engine.Input.KeysHeld[sdl.K_DOWN]
+		s.Rolling = engine.Input.KeysHeld[sdl.K_DOWN] && s.Grounded
+		s.Shooting = engine.Input.KeysHeld[sdl.K_RSHIFT]
+
+		s.Grounded = transform.Sensor.Bottom
+		s.LeftSlide = transform.Sensor.Left
+		s.RightSlide = transform.Sensor.Right
+		s.Sliding = (s.LeftSlide || s.RightSlide) && !s.Grounded
+
+		// s.Jumping = !s.Grounded
+
+		if s.Grounded {
+			s.Jumping = false
+			s.JumpCount = 0
+			s.JumpFrameCount = 0
+		}
+
+		if s.MoveLeft {
+			s.State = ENTITY_STATE_LEFT
+			s.Orientation = ORIENTATION_LEFT
+		} else if s.MoveRight  {
+			s.State = ENTITY_STATE_RIGHT
+			s.Orientation = ORIENTATION_RIGHT
+		} else {
+			s.State = ENTITY_STATE_IDLE
+		}
+
+		if !s.Grounded {
+			s.State = ENTITY_STATE_JUMP
+		}
+		if s.Rolling {
+			s.State = ENTITY_STATE_ROLL
+		}
+		if s.Shooting {
+			s.State = ENTITY_STATE_SHOOT
+		}
+
+		/**
+		 * Wall Sliding
+		 */
+		if s.RightSlide && !s.Grounded {
+			s.State = ENTITY_STATE_WALLR
+			s.JumpCount = 0
+		}
+		if s.LeftSlide && !s.Grounded {
+			s.State = ENTITY_STATE_WALLL
+			s.JumpCount = 0
+		}
+
+		/**
+		 * Climbing
+		 */
+		is.Climb(world, s, transform)
+
+		/**
+		 * Jumping
+		 */
+		if engine.Input.KeysHeld[sdl.K_SPACE] {
+			s.JumpFrameCount++
+		}
+
+		// under water jumping is swimming, there's no limit on strokes
+		var water *Water
+		if transform.Sensor.Water != ENTITY_NONE {
+			water = world.GetWater(transform.Sensor.Water)
+		}
+
+		// down and jump on a one-way platform drops through it instead
+		dropping := engine.Input.KeysHeld[sdl.K_DOWN] && transform.Sensor.OneWay
+		if engine.Input.KeyState(sdl.K_SPACE).JustPressed() && dropping {
+			s.Dropping = true
+			s.Rolling = false
+			s.State = ENTITY_STATE_JUMP
+		} else if engine.Input.KeyState(sdl.K_SPACE).JustPressed()  && (s.JumpCount < 2 || water != nil) {
+			s.Jumping = true
+			// jumping off a ladder
+			s.Climbing = false
+
+			// For jumping purposes sliding is the same as being on the ground
+			if !s.Sliding {
+				s.JumpCount++
 			}
 
-			if s.MoveLeft {
-				s.State = ENTITY_STATE_LEFT
-				s.Orientation = ORIENTATION_LEFT
-			} else if s.MoveRight  {
+			// determine jump height and
+			var speedX, speedY float32
+			speedY = body.JumpSpeed
+			if s.LeftSlide && !s.Grounded {
+				speedX = body.WallJumpSpeed
 				s.State = ENTITY_STATE_RIGHT
 				s.Orientation = ORIENTATION_RIGHT
+			} else if s.RightSlide && !s.Grounded {
+				speedX = -body.WallJumpSpeed
+				s.State = ENTITY_STATE_LEFT
+				s.Orientation = ORIENTATION_LEFT
 			} else {
-				s.State = ENTITY_STATE_IDLE
+				speedX = 0
 			}
 
-			if !s.Grounded {
-				s.State = ENTITY_STATE_JUMP
-			}
-			if s.Rolling {
-				s.State = ENTITY_STATE_ROLL
+			// the second jump in the air, a wall jump is a first jump
+			if s.JumpCount > 1 && !s.Sliding {
+				speedY *= body.DoubleJumpScale
 			}
-			if s.Shooting {
-				s.State = ENTITY_STATE_SHOOT
+			if water != nil {
+				// a stroke replaces the speed the entity was sinking at
+				speedY = body.JumpSpeed * water.Jump - transform.SpeedY
 			}
 
-			/**
-			 * Wall Sliding
-			 */
-			if s.RightSlide && !s.Grounded {
-				s.State = ENTITY_STATE_WALLR
-				s.JumpCount = 0
-			}
-			if s.LeftSlide && !s.Grounded {
-				s.State = ENTITY_STATE_WALLL
-				s.JumpCount = 0
-			}
-
-			/**
-			 * Jumping
-			 */
-			if engine.Input.KeysHeld[sdl.K_SPACE] {
-				s.JumpFrameCount++
-			}
+			// emit physics pulse event
+			world.Events.EmitEvent(&PhysicsPulseEvent{
+				Entity: entity,
+				SpeedX: speedX,
+				SpeedY: speedY,
+			})
+			// Emit jump Audio Event
+			world.Events.EmitEvent(&AudioEvent{ Clip: "jump.wav" })
+		}
 
-			if engine.Input.KeyState(sdl.K_SPACE).JustPressed()  && s.JumpCount < 2 {
-				s.Jumping = true
-
-				// For jumping purposes sliding is the same as being on the ground
-				if !s.Sliding {
-					s.JumpCount++
-				}
-
-				// determine jump height and
-				var speedX, speedY float32
-				speedY = -5
-				if s.LeftSlide && !s.Grounded {
-					speedX = 70
-					s.State = ENTITY_STATE_RIGHT
-					s.Orientation = ORIENTATION_RIGHT
-				} else if s.RightSlide && !s.Grounded {
-					speedX = -5
-					s.State = ENTITY_STATE_LEFT
-					s.Orientation = ORIENTATION_LEFT
-				} else {
-					speedX = 0
-				}
-
-				// emit physics pulse event
-				world.Events.EmitEvent(&PhysicsPulseEvent{
-					Entity: entity,
-					SpeedX: speedX,
-					SpeedY: speedY / float32(s.JumpCount / 2),
-				})
-				// Emit jump Audio Event
-				world.Events.EmitEvent(&AudioEvent{ Clip: "jump.wav" })
-			}
+		// letting go early cuts a jump short, but not a swimming stroke
+		if s.Jumping && engine.Input.KeyState(sdl.K_SPACE).JustReleased() && !s.Sliding && s.JumpFrameCount < 25 && water == nil {
+			s.Jumping = false
+			s.JumpFrameCount = 0
+			world.Events.EmitEvent(&PhysicsPulseEvent{
+				Entity: entity,
+				SpeedX: 0,
+				SpeedY: 2,
+			})
+		}
+	}
+}
 
-			if s.Jumping && engine.Input.KeyState(sdl.K_SPACE).JustReleased() && !s.Sliding && s.JumpFrameCount < 25 {
-				s.Jumping = false
-				s.JumpFrameCount = 0
-				world.Events.EmitEvent(&PhysicsPulseEvent{
-					Entity: entity,
-					SpeedX: 0,
-					SpeedY: 2,
-				})
+// Climb grabs and lets go of ladders.  Up over a ladder, or down while
+// standing on top of one, starts climbing.  The entity lets go when it
+// climbs off either end, reaches the floor holding down or jumps.
+func (is *InputSystem) Climb(world *World, s *State, transform *Transform) {
+	ladder := transform.Sensor.Ladder
+	if !s.Climbing {
+		onTop := transform.Sensor.Platform != ENTITY_NONE && world.HasComponents(transform.Sensor.Platform, COMPONENT_LADDER)
+		// after jumping off a ladder it can only be grabbed again once
+		// the jump is falling
+		canGrab := !s.Jumping || transform.SpeedY > 0
+		if s.MoveUp && ladder != ENTITY_NONE && canGrab {
+			s.Climbing = true
+		} else if s.MoveDown && onTop {
+			ladder = transform.Sensor.Platform
+			s.Climbing = true
+			// through the platform at the top of the ladder
+			s.Dropping = true
+		} else {
+			return
+		}
+		// line up with the middle of the ladder
+		ladderTransform := world.GetTransform(ladder)
+		transform.X = ladderTransform.X + float32(ladderTransform.W - transform.W) / 2
+		transform.SpeedX = 0
+		transform.SpeedY = 0
+	} else if ladder == ENTITY_NONE || (s.Grounded && s.MoveDown) {
+		s.Climbing = false
+		// stop rather than carry on up past the top
+		transform.SpeedY = 0
+		// off the top the bottom can still be inside the top ladder, put
+		// it on top so it lands on the ladder's platform
+		if ladder == ENTITY_NONE {
+			if top := world.LadderAt(transform.X + float32(transform.W) / 2, transform.Y + float32(transform.H)); top != ENTITY_NONE {
+				transform.Y = world.GetTransform(top).Y - float32(transform.H)
 			}
 		}
+		return
 	}
+
+	s.State = ENTITY_STATE_CLIMB
+	s.Jumping = false
+	s.JumpCount = 0
+	s.MoveLeft = false
+	s.MoveRight = false
+	s.Rolling = false
+	s.Sliding = false
 }
 
 type PhysicsSystem struct {
 	transform *Transform
 	stateCmp *State
+	body *PhysicsBody
 	SystemEvents
 }
 
+func (ps *PhysicsSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "physics",
+		Phase: PHASE_SIMULATION,
+	}
+}
 func (ps *PhysicsSystem) Init(world *World) {
-	world.Events.Subscribe("physics-pulse", ps)
+	Queue[*PhysicsPulseEvent](world.Events, ps)
 }
 func (ps *PhysicsSystem) Update(engine *Engine, world *World) {
 	ps.SystemEvents.HandleEvents(func(event Event) {
 		evt, _ := event.(*PhysicsPulseEvent)
-		mask := world.Mask[evt.Entity]
-		if signatureMatches(mask, COMPONENT_VELOCITY|COMPONENT_STATE|COMPONENT_CONTROLLER) {
+		if world.HasComponents(evt.Entity, COMPONENT_VELOCITY|COMPONENT_STATE|COMPONENT_CONTROLLER) {
 			transform := world.GetTransform(evt.Entity)
 			transform.SpeedX += evt.SpeedX
 			transform.SpeedY += evt.SpeedY
 		}
 	})
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_TRANSFORM|COMPONENT_STATE|COMPONENT_CONTROLLER) {
-			ps.transform = world.GetTransform(entity)
-			ps.stateCmp = world.GetState(entity)
-
-			if !ps.stateCmp.MoveLeft && !ps.stateCmp.MoveRight {
-				ps.StopMove()
-			}
-			if ps.stateCmp.MoveLeft {
-				ps.transform.AccelX = -0.2
-			} else if ps.stateCmp.MoveRight {
-				ps.transform.AccelX = 0.2
+	for _, entity := range world.Query(COMPONENT_TRANSFORM|COMPONENT_STATE) {
+		// controlled entities move with the default body if they don't
+		// have their own
+		if !world.HasComponents(entity, COMPONENT_CONTROLLER) && !world.HasComponents(entity, COMPONENT_PHYSICS_BODY) {
+			continue
+		}
+		ps.transform = world.GetTransform(entity)
+		ps.stateCmp = world.GetState(entity)
+		ps.body = world.GetPhysicsBody(entity)
+
+		// no gravity on a ladder, up and down climb at a fixed speed
+		if ps.stateCmp.Climbing {
+			ps.transform.AccelX = 0
+			ps.transform.AccelY = 0
+			ps.transform.SpeedX = 0
+			ps.transform.SpeedY = 0
+			if ps.stateCmp.MoveUp {
+				ps.transform.SpeedY = -ps.body.ClimbSpeed
+			} else if ps.stateCmp.MoveDown {
+				ps.transform.SpeedY = ps.body.ClimbSpeed
 			}
+			continue
+		}
+
+		if !ps.stateCmp.MoveLeft && !ps.stateCmp.MoveRight {
+			ps.StopMove()
+		}
+		if ps.stateCmp.MoveLeft {
+			ps.transform.AccelX = -ps.body.Accel
+		} else if ps.stateCmp.MoveRight {
+			ps.transform.AccelX = ps.body.Accel
+		}
 
-			if ps.stateCmp.Rolling {
-				if ps.stateCmp.Orientation == ORIENTATION_LEFT {
-					ps.transform.AccelX = -2
-				} else if ps.stateCmp.Orientation == ORIENTATION_RIGHT {
-					ps.transform.AccelX = 2
-				}
+		if ps.stateCmp.Rolling {
+			if ps.stateCmp.Orientation == ORIENTATION_LEFT {
+				ps.transform.AccelX = -ps.body.RollAccel
+			} else if ps.stateCmp.Orientation == ORIENTATION_RIGHT {
+				ps.transform.AccelX = ps.body.RollAccel
 			}
+		}
 
-			// apply gravity
-			ps.transform.AccelY = .13
+		var water *Water
+		if ps.transform.Sensor.Water != ENTITY_NONE {
+			water = world.GetWater(ps.transform.Sensor.Water)
+		}
 
-			if ps.stateCmp.Sliding && ps.transform.SpeedY > 0 {
-				ps.transform.SpeedY /= 2
-			}
+		// apply gravity
+		ps.transform.AccelY = GRAVITY * ps.body.GravityScale
+		if water != nil {
+			ps.transform.AccelY *= water.Gravity
+		}
 
-			ps.transform.SpeedX += ps.transform.AccelX * engine.FPS.GetSpeedFactor()
-			ps.transform.SpeedY += ps.transform.AccelY * engine.FPS.GetSpeedFactor()
+		if ps.stateCmp.Sliding && ps.transform.SpeedY > 0 {
+			ps.transform.SpeedY /= 2
+		}
 
-			var maxSpeedX, maxSpeedY float32
-			if !ps.stateCmp.Rolling {
-				maxSpeedX = ps.transform.MaxSpeedX
-				maxSpeedY = ps.transform.MaxSpeedY
-			} else {
-				maxSpeedX = ps.transform.MaxSpeedX + 2
-				maxSpeedY = ps.transform.MaxSpeedY + 2
-			}
-			if ps.transform.SpeedX > ps.transform.MaxSpeedX { ps.transform.SpeedX = maxSpeedX }
-			if ps.transform.SpeedX < -ps.transform.MaxSpeedX { ps.transform.SpeedX = -maxSpeedX }
-			if ps.transform.SpeedY > ps.transform.MaxSpeedY { ps.transform.SpeedY = maxSpeedY }
-			if ps.transform.SpeedY < -ps.transform.MaxSpeedY { ps.transform.SpeedY = -maxSpeedY }
+		ps.transform.SpeedX += ps.transform.AccelX
+		ps.transform.SpeedY += ps.transform.AccelY
+
+		var maxSpeedX, maxSpeedY float32
+		if !ps.stateCmp.Rolling {
+			maxSpeedX = ps.body.MaxSpeedX
+			maxSpeedY = ps.body.MaxSpeedY
+		} else {
+			maxSpeedX = ps.body.MaxSpeedX + ps.body.RollSpeed
+			maxSpeedY = ps.body.MaxSpeedY + ps.body.RollSpeed
+		}
+		if ps.transform.SpeedX > ps.body.MaxSpeedX { ps.transform.SpeedX = maxSpeedX }
+		if ps.transform.SpeedX < -ps.body.MaxSpeedX { ps.transform.SpeedX = -maxSpeedX }
+		if ps.transform.SpeedY > ps.body.MaxSpeedY { ps.transform.SpeedY = maxSpeedY }
+		if ps.transform.SpeedY < -ps.body.MaxSpeedY { ps.transform.SpeedY = -maxSpeedY }
+
+		// water slows everything down
+		if water != nil {
+			ps.transform.SpeedX = clampSpeed(ps.transform.SpeedX, maxSpeedX * water.MaxSpeed)
+			ps.transform.SpeedY = clampSpeed(ps.transform.SpeedY, maxSpeedY * water.MaxSpeed)
 		}
 	}
 }
 
+func clampSpeed(speed, max float32) float32 {
+	if speed > max {
+		return max
+	}
+	if speed < -max {
+		return -max
+	}
+	return speed
+}
+
 func (ps *PhysicsSystem) StopMove() {
 	if ps.transform.SpeedX > 0 {
-		ps.transform.AccelX = -.3
+		ps.transform.AccelX = -ps.body.Friction
 	}
 
 	if ps.transform.SpeedX < 0 {
-		ps.transform.AccelX = .3
+		ps.transform.AccelX = ps.body.Friction
 	}
 
 	if ps.transform.SpeedX < .18 && ps.transform.SpeedX > -.18 {
@@ -333,185 +501,352 @@ type MovementSystem struct {
 	stateCmp *State
 	engine *Engine
 	world *World
-	currentEntity int
+	currentEntity Entity
 	SystemEvents
 }
+func (ms *MovementSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "movement",
+		Phase: PHASE_SIMULATION,
+		After: []string{"physics"},
+	}
+}
 func (ms *MovementSystem) Init(world *World) {}
 func (ms *MovementSystem) Update(engine *Engine, world *World) {
 	ms.engine = engine
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_TRANSFORM|COMPONENT_VELOCITY|COMPONENT_STATE) {
-			ms.transform = world.GetTransform(entity)
-			ms.stateCmp = world.GetState(entity)
-			ms.world = world
-			ms.currentEntity = entity
-			ms.Move(ms.transform.SpeedX, ms.transform.SpeedY)
-		}
+	for _, entity := range world.Query(COMPONENT_TRANSFORM|COMPONENT_VELOCITY|COMPONENT_STATE) {
+		ms.transform = world.GetTransform(entity)
+		ms.stateCmp = world.GetState(entity)
+		ms.world = world
+		ms.currentEntity = entity
+		ms.Move(ms.transform.SpeedX, ms.transform.SpeedY)
 	}
 }
 
 func (ms *MovementSystem) Move(moveX, moveY float32) {
-	if moveX == 0 && moveY == 0 {
-		return
+	// move along with the platform being stood on
+	moveX += ms.transform.CarryX
+	moveY += ms.transform.CarryY
+	ms.transform.CarryX = 0
+	ms.transform.CarryY = 0
+	movingDown := moveY > 0
+	bottom := ms.transform.Y + float32(ms.transform.H)
+	wasGrounded := ms.stateCmp.Grounded
+
+	// on a slope the bottom of the box is raised for the horizontal move
+	// so walking off the top of the slope doesn't catch the edge of the
+	// tile beside it, following the ground puts it back down
+	lift := ms.engine.Map.TileSize / 2
+	if ms.transform.Sensor.Slope && ms.transform.H > lift {
+		ms.Sweep(moveX, 0, lift)
+		ms.Sweep(0, moveY, 0)
+	} else {
+		ms.Sweep(moveX, moveY, 0)
 	}
-
-	var newX float32 = 0.0
-	var newY float32 = 0.0
-
-	moveX *= ms.engine.FPS.GetSpeedFactor()
-	moveY *= ms.engine.FPS.GetSpeedFactor()
-
-	// if X or Y are not zero, start collision checking
-	// at the current X + speed factor and current Y + speed factor
-	if moveX != 0 {
-		if moveX >= 0 {
-			newX = ms.engine.FPS.GetSpeedFactor()
-		} else {
-			newX = -ms.engine.FPS.GetSpeedFactor()
-		}
+	if moveY >= 0 {
+		ms.FollowGround(wasGrounded)
 	}
+	ms.LandOnPlatforms(bottom)
 
-	if moveY != 0 {
-		if moveY >= 0 {
-			newY = ms.engine.FPS.GetSpeedFactor()
-		} else {
-			newY = -ms.engine.FPS.GetSpeedFactor()
-		}
+	// once the entity has started moving down into the one-way tile it is
+	// inside it, and tiles aren't checked again until they're re-entered
+	if movingDown {
+		ms.stateCmp.Dropping = false
 	}
+	ms.UpdateSensors()
+}
+
+// Sweep moves the entity against the map with the bottom of its box
+// raised by lift
+func (ms *MovementSystem) Sweep(moveX, moveY float32, lift int32) {
+	// sweep the whole move against the map, when something is hit stop
+	// at it and slide the rest of the way along the surface.  Two hits
+	// (a wall then the floor) use up both axes.
+	for i := 0; i < 2 && (moveX != 0 || moveY != 0); i++ {
+		hit := ms.engine.Map.SweepBox(ms.transform.X, ms.transform.Y, ms.transform.W, ms.transform.H - lift, moveX, moveY, ms.stateCmp.Dropping)
+		ms.transform.X = hit.X
+		ms.transform.Y = hit.Y
+		if !hit.Hit {
+			break
+		}
 
-	// starting at speed factor (+ X, + Y) keep iterating over each new desired
-	// move, checking for collisions along the way
-	for {
-		// check for collision at desired X position
-		if ms.PosValid(int32(ms.transform.X+ newX), int32(ms.transform.Y)) {
-			// no collision, update position
-			ms.transform.X += newX
-		} else {
+		remaining := 1 - hit.Time
+		moveX *= remaining
+		moveY *= remaining
+		if hit.NormalX != 0 {
 			// collision detected, set speed to zero so we don't allow the movement
 			ms.transform.SpeedX = 0
+			moveX = 0
 		}
-
-		// check for collision at desired Y
-		if ms.PosValid(int32(ms.transform.X), int32(ms.transform.Y+ newY)) {
-			// no collision, update position
-			ms.transform.Y += newY
-		} else {
-			// collision detected, set speed to zero so we don't allow the movement
+		if hit.NormalY != 0 {
 			ms.transform.SpeedY = 0
-			if moveY > 0 {
+			moveY = 0
+			if hit.NormalY < 0 {
 				// reset jump flags
 				// TODO: Possibly raise events for these
 				ms.stateCmp.Grounded = true
 				ms.stateCmp.Jumping = false
 			}
 		}
-
-		// subtract from newX and newY the distance we've moved (or not moved)
-		moveX += -newX
-		moveY += -newY
-
-		// normalize values in case any have gone negative
-		if newX > 0 && moveX <= 0 { newX = 0 }
-		if newX < 0 && moveX >= 0 { newX = 0 }
-
-		if newY > 0 && moveY <= 0 { newY = 0 }
-		if newY < 0 && moveY >= 0 { newY = 0 }
-
-		if moveX == 0 { newX = 0 }
-		if moveY == 0 { newY = 0 }
-
-		// if either of these conditions are true, we're done checking
-		// and it's time to exit the for loop
-		if moveX == 0 && moveY == 0 { break }
-		if newX == 0 && newY == 0 { break }
 	}
 }
 
+// FollowGround keeps the entity on slopes.  Slope tiles aren't solid to
+// the sweep, so anything that has sunk into one is lifted back onto its
+// surface, and an entity that was grounded is pulled down onto floor just
+// below it rather than skipping down a slope in the air.
+func (ms *MovementSystem) FollowGround(wasGrounded bool) {
+	step := float32(ms.engine.Map.TileSize / 2)
+	bottom := ms.transform.Y + float32(ms.transform.H)
+	var below float32
+	if wasGrounded {
+		below = step
+	}
 
-func (ms *MovementSystem) PosValid(newX int32, newY int32) bool {
-	retVal := true
-	startX := newX
-	startY := newY
-
-	endX := newX + ms.transform.W - 1
-	endY := newY + ms.transform.H - 1
+	x := int32(ms.transform.X) + ms.transform.W / 2
+	surface, ok := ms.engine.Map.GroundSurface(x, bottom, bottom - step, bottom + below, ms.stateCmp.Dropping)
+	if !ok {
+		return
+	}
+	ms.transform.Y = surface - float32(ms.transform.H)
+	if ms.transform.SpeedY > 0 {
+		ms.transform.SpeedY = 0
+	}
+	ms.stateCmp.Grounded = true
+	ms.stateCmp.Jumping = false
+}
 
-	for iY := startY; iY <= endY; iY++ {
-		for iX := startX; iX <= endX; iX++ {
-			if ms.engine.Map.PointCollidesTile(iX, iY) {
-				retVal = false
-			}
+// LandOnPlatforms stops the entity falling through the top of a platform
+// entity.  Platforms are one-way, bottom is where the entity's bottom edge
+// was before it moved and it has to have been on or above the top of the
+// platform before the platform moved.
+func (ms *MovementSystem) LandOnPlatforms(bottom float32) {
+	ms.transform.Sensor.Platform = ENTITY_NONE
+	if ms.stateCmp.Dropping {
+		return
+	}
+	var landed Entity
+	var surface float32
+	for _, entity := range ms.world.Query(COMPONENT_PLATFORM|COMPONENT_TRANSFORM) {
+		if entity == ms.currentEntity {
+			continue
 		}
+		platform := ms.world.GetTransform(entity)
+		// allow for rounding in standing exactly on top
+		if bottom > platform.PrevY + 0.01 || ms.transform.Y + float32(ms.transform.H) < platform.Y {
+			continue
+		}
+		if ms.transform.X >= platform.X + float32(platform.W) || ms.transform.X + float32(ms.transform.W) <= platform.X {
+			continue
+		}
+		if landed == ENTITY_NONE || platform.Y < surface {
+			landed = entity
+			surface = platform.Y
+		}
+	}
+	if landed == ENTITY_NONE {
+		return
+	}
+	ms.transform.Y = surface - float32(ms.transform.H)
+	if ms.transform.SpeedY > 0 {
+		ms.transform.SpeedY = 0
 	}
+	ms.stateCmp.Grounded = true
+	ms.stateCmp.Jumping = false
+	ms.transform.Sensor.Platform = landed
+}
 
-	// sensors
+// UpdateSensors checks for solid tiles just outside each side of the
+// entity's bounding box
+func (ms *MovementSystem) UpdateSensors() {
 	// TODO: Make bottom sensor (at least) a line that extends full width of the entity BB
 	top, bottom, left, right := ms.transform.GetSensorPoints()
 	ms.transform.Sensor.Top = ms.engine.Map.PointCollidesTile(top.X, top.Y)
 	ms.transform.Sensor.Bottom = ms.engine.Map.PointCollidesTile(bottom.X, bottom.Y)
+	// one-way tiles only count underneath, and only when standing on top
+	ms.transform.Sensor.OneWay = !ms.transform.Sensor.Bottom &&
+		(ms.engine.Map.PointOnOneWayTop(bottom.X, bottom.Y, ms.transform.Y + float32(ms.transform.H)) ||
+		ms.transform.Sensor.Platform != ENTITY_NONE)
+	// the bottom sensor is in line with the point FollowGround keeps on
+	// the slope surface
+	ms.transform.Sensor.Slope = ms.engine.Map.PointOnSlope(bottom.X, bottom.Y)
+	ms.transform.Sensor.Bottom = ms.transform.Sensor.Bottom || ms.transform.Sensor.OneWay || ms.transform.Sensor.Slope
 	ms.transform.Sensor.Left = ms.engine.Map.PointCollidesTile(left.X, left.Y)
 	ms.transform.Sensor.Right = ms.engine.Map.PointCollidesTile(right.X, right.Y)
+	// the lowest pixel row of the entity, so standing on top of a ladder
+	// isn't over it
+	ms.transform.Sensor.Ladder = ms.world.LadderAt(ms.transform.X + float32(ms.transform.W) / 2, ms.transform.Y + float32(ms.transform.H) - 1)
+
+	water := ms.world.FindAt(COMPONENT_WATER, ms.transform.X + float32(ms.transform.W) / 2, ms.transform.Y + float32(ms.transform.H) / 2)
+	if old := ms.transform.Sensor.Water; water != old {
+		if old != ENTITY_NONE {
+			ms.world.Events.EmitEvent(&WaterExitEvent{Entity: ms.currentEntity, Water: old})
+		}
+		if water != ENTITY_NONE {
+			ms.world.Events.EmitEvent(&WaterEnterEvent{Entity: ms.currentEntity, Water: water})
+		}
+		ms.transform.Sensor.Water = water
+	}
+}
 
-	return retVal
+// PlatformSystem moves platform entities along their paths and carries
+// anything standing on them
+type PlatformSystem struct {
+	SystemEvents
+}
+func (ps *PlatformSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "platform",
+		Phase: PHASE_SIMULATION,
+		After: []string{"physics"},
+		Before: []string{"movement"},
+	}
+}
+func (ps *PlatformSystem) Init(world *World) {}
+func (ps *PlatformSystem) Update(engine *Engine, world *World) {
+	for _, entity := range world.Query(COMPONENT_PLATFORM|COMPONENT_TRANSFORM) {
+		platform := world.GetPlatform(entity)
+		if len(platform.Path) < 2 {
+			continue
+		}
+		transform := world.GetTransform(entity)
+		oldX, oldY := transform.X, transform.Y
+		platform.Advance(platform.Speed)
+		world.PlacePlatform(entity)
+		// riders landing this tick check against where the top was, set
+		// here as well as by StorePreviousTransforms so platforms without
+		// COMPONENT_VELOCITY still carry
+		transform.PrevX, transform.PrevY = oldX, oldY
+
+		// riders are moved by the movement system so they still collide
+		// with the map
+		for _, rider := range world.Query(COMPONENT_TRANSFORM|COMPONENT_VELOCITY|COMPONENT_STATE) {
+			riderTransform := world.GetTransform(rider)
+			if riderTransform.Sensor.Platform == entity {
+				riderTransform.CarryX += transform.X - oldX
+				riderTransform.CarryY += transform.Y - oldY
+			}
+		}
+	}
 }
 
 type CameraSystem struct {
-	targeted bool
 	SystemEvents
 }
+func (cs *CameraSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "camera",
+		Phase: PHASE_RENDER,
+		Before: []string{"map-render"},
+	}
+}
 func (cs *CameraSystem) Init(world *World) {}
 func (cs *CameraSystem) Update(engine *Engine, world *World) {
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_FOCUSED) && !cs.targeted {
-			pos := world.GetTransform(entity)
-			engine.Camera.SetTarget(&pos.X, &pos.Y)
-			cs.targeted = true
-		}
+	// follow the interpolated position so the focused entity
+	// doesn't jitter against the rest of the scene
+	for _, entity := range world.Query(COMPONENT_FOCUSED|COMPONENT_TRANSFORM) {
+		engine.Camera.Follow(world.RenderPosition(entity, engine.Interpolation()))
 	}
 }
 
+// contactPair is two overlapping entities, a has the lower slot index
+type contactPair struct {
+	a Entity
+	b Entity
+}
+
 type EntityCollisionSystem struct {
-	SystemEvents
+	// pairs overlapping as of the last update, in the order they were found
+	contacts []contactPair
+	touching map[contactPair]bool
+	// scratch space reused every update
+	bounds []collider
+}
+
+type collider struct {
+	entity Entity
+	bb sdl.Rect
+}
+
+func (ecs *EntityCollisionSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "entity-collision",
+		Phase: PHASE_POST_SIMULATION,
+	}
+}
+func (ecs *EntityCollisionSystem) Init(world *World) {
+	ecs.touching = make(map[contactPair]bool)
 }
-func (ecs *EntityCollisionSystem) Init(world *World) {}
 func (ecs *EntityCollisionSystem) Update(engine *Engine, world *World) {
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_CONTROLLER|COMPONENT_TRANSFORM) {
-			collidableEntities := world.GetColliders()
-			for _, id := range collidableEntities {
-				transformA := world.GetTransform(entity)
-				transformB := world.GetTransform(id)
-				a := transformA.GetBB()
-				b := transformB.GetBB()
-				if world.Collides(a, b) && entity != id {
-					world.Events.EmitEvent(&CollisionEvent{ A: entity, B: id })
-				}
+	// sweep along x, a pair can only overlap if their x ranges do
+	ecs.bounds = ecs.bounds[:0]
+	for _, entity := range world.GetColliders() {
+		ecs.bounds = append(ecs.bounds, collider{entity, world.GetWorldBB(entity)})
+	}
+	sort.SliceStable(ecs.bounds, func(i, j int) bool {
+		return ecs.bounds[i].bb.X < ecs.bounds[j].bb.X
+	})
+
+	var contacts []contactPair
+	touching := make(map[contactPair]bool)
+	for i, first := range ecs.bounds {
+		for _, second := range ecs.bounds[i+1:] {
+			if second.bb.X >= first.bb.X + first.bb.W {
+				break
+			}
+			if !world.Collides(first.bb, second.bb) {
+				continue
+			}
+			pair := contactPair{first.entity, second.entity}
+			if pair.b.Index() < pair.a.Index() {
+				pair.a, pair.b = pair.b, pair.a
+			}
+			contacts = append(contacts, pair)
+			touching[pair] = true
+
+			if ecs.touching[pair] {
+				world.Events.EmitEvent(&CollisionStayEvent{ A: pair.a, B: pair.b })
+			} else {
+				world.Events.EmitEvent(&CollisionEnterEvent{ A: pair.a, B: pair.b })
 			}
 		}
 	}
+	for _, pair := range ecs.contacts {
+		if !touching[pair] {
+			world.Events.EmitEvent(&CollisionExitEvent{ A: pair.a, B: pair.b })
+		}
+	}
+	ecs.contacts = contacts
+	ecs.touching = touching
 }
 
 type EntityCollectionSystem struct {
-	SystemEvents
-	subs []Subscription
+	subs []*Subscription
+}
+func (ecs *EntityCollectionSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "entity-collection",
+		Phase: PHASE_POST_SIMULATION,
+		After: []string{"entity-collision"},
+	}
 }
 func (ecs *EntityCollectionSystem) Init(world *World) {
-	ecs.subs = append(ecs.subs, world.Events.Subscribe("collision", ecs))
-}
-func (ecs *EntityCollectionSystem) Update(engine *Engine, world *World) {
-	ecs.SystemEvents.HandleEvents(func(event Event) {
-		evt, ok := event.(*CollisionEvent)
-		if !ok {
-			fmt.Fprintf(os.Stderr, "Event type: %s is not a valid Collsision Event", event.Type())
-		}
-		// fmt.Fprintf(os.Stdout, "Collision event A: %d\t\t\tB:%d\n", evt.A, evt.B)
-		maskA := world.Mask[evt.A]
-		maskB := world.Mask[evt.B]
-
-		if signatureMatches(maskA, COMPONENT_INVENTORY) && signatureMatches(maskB, COMPONENT_COLLECTIBLE) {
-			world.DestroyEntity(evt.B)
-			inventory := world.Inventory[evt.A]
-			collectible := world.Collectible[evt.B]
+	// handled during the end of frame flush so the collection event it
+	// emits reaches the hud in the same frame
+	ecs.subs = append(ecs.subs, On(world.Events, func(evt *CollisionEnterEvent) {
+		// either side may have been destroyed by an earlier event this frame
+		if !world.Alive(evt.A) || !world.Alive(evt.B) {
+			return
+		}
+		collector, item := evt.A, evt.B
+		if !world.HasComponents(collector, COMPONENT_INVENTORY) {
+			collector, item = item, collector
+		}
+
+		if world.HasComponents(collector, COMPONENT_INVENTORY) && world.HasComponents(item, COMPONENT_COLLECTIBLE) {
+			inventory := world.GetInventory(collector)
+			collectible := world.GetCollectible(item)
+			world.Commands.DestroyEntity(item)
 			inventory.Items[collectible.Type] += collectible.Value
 			world.Events.EmitEvent(&CollectionEvent{
 				Collectible: collectible.Type,
@@ -519,16 +854,30 @@ func (ecs *EntityCollectionSystem) Update(engine *Engine, world *World) {
 				NumCollected: collectible.Value,
 			})
 		}
-	})
+	}))
 }
+func (ecs *EntityCollectionSystem) Update(engine *Engine, world *World) {}
 
 type AudioSystem struct {
 	SystemEvents
-	sub Subscription
+	subs []*Subscription
 }
+func (as *AudioSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "audio",
+		Phase: PHASE_POST_SIMULATION,
+		After: []string{"entity-collection"},
+	}
+}
+// sounds for events delivered in the end of frame flush play at the start
+// of the next tick
 func (as *AudioSystem) Init(world *World) {
-	as.sub = world.Events.Subscribe("collection", as)
-	as.sub = world.Events.Subscribe("audio", as)
+	as.subs = append(as.subs,
+		Queue[*CollectionEvent](world.Events, as),
+		Queue[*AudioEvent](world.Events, as),
+		Queue[*WaterEnterEvent](world.Events, as),
+		Queue[*WaterExitEvent](world.Events, as),
+	)
 }
 func (as *AudioSystem) Update(engine *Engine, world *World) {
 	as.HandleEvents(func(event Event) {
@@ -542,48 +891,122 @@ func (as *AudioSystem) Update(engine *Engine, world *World) {
 			}
 		case *AudioEvent:
 			engine.Audio.PlaySoundEffect(evt.Clip)
+		case *WaterEnterEvent:
+			as.splash(engine, world, evt.Water)
+		case *WaterExitEvent:
+			as.splash(engine, world, evt.Water)
 		default:
 
 		}
 	})
 }
 
-type TextRenderSystem struct {
+func (as *AudioSystem) splash(engine *Engine, world *World, entity Entity) {
+	if water := world.GetWater(entity); water != nil && water.Splash != "" {
+		engine.Audio.PlaySoundEffect(water.Splash)
+	}
+}
+
+// WaterRenderSystem draws the surface of each water region over the
+// entities in it
+type WaterRenderSystem struct {
 	SystemEvents
 }
-func (trs *TextRenderSystem) Init(world *World) {}
-func (trs *TextRenderSystem) Update(engine *Engine, world *World) {
-	for entity, mask := range world.Mask {
-		if signatureMatches(mask, COMPONENT_TRANSFORM|COMPONENT_TEXT) {
-			transform := world.GetTransform(entity)
-			text := world.GetText(entity)
-			cacheId := strconv.Itoa(entity)
-			engine.Text.Write(cacheId, text.Value)
-			texture := engine.Text.GetTexture(cacheId)
-			if texture != nil {
-				engine.Graphics.DrawFull(texture, int32(transform.X), int32(transform.Y))
+func (wrs *WaterRenderSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "water-render",
+		Phase: PHASE_RENDER,
+		After: []string{"render"},
+	}
+}
+func (wrs *WaterRenderSystem) Init(world *World) {}
+func (wrs *WaterRenderSystem) Update(engine *Engine, world *World) {
+	frames := engine.Assets.Get("Objects/Water/watertop")
+	if len(frames) == 0 {
+		return
+	}
+	frame := frames[0]
+	for _, entity := range world.Query(COMPONENT_WATER|COMPONENT_TRANSFORM) {
+		transform := world.GetTransform(entity)
+		x := int32(transform.X - engine.Camera.X())
+		y := int32(transform.Y - engine.Camera.Y())
+		if engine.Config.DrawDebug {
+			engine.Graphics.DrawRectOutline(x, y, transform.W, transform.H)
+		}
+		// repeat the surface across the width, cutting the last one short
+		for offset := int32(0); offset < transform.W; offset += frame.W {
+			w := frame.W
+			if offset + w > transform.W {
+				w = transform.W - offset
 			}
+			engine.Graphics.DrawPart(engine.Assets.Texture, x + offset, y, frame.X, frame.Y, w, frame.H, sdl.FLIP_NONE)
+		}
+	}
+}
+
+type TextRenderSystem struct {
+	sub *Subscription
+	// set on the first Update, nothing is cached before then
+	text TextManager
+}
+func (trs *TextRenderSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "text-render",
+		Phase: PHASE_HUD,
+	}
+}
+func (trs *TextRenderSystem) Init(world *World) {
+	// free cached textures for entities that no longer have text, this
+	// covers destroyed entities too since destroying clears every
+	// component.  Handled as they happen rather than queued, headless
+	// runs never render so a queue would never be drained.
+	trs.sub = On(world.Events, func(evt *ComponentRemovedEvent) {
+		if evt.Component == COMPONENT_TEXT && trs.text != nil {
+			trs.text.Remove(textCacheId(evt.Entity))
+		}
+	})
+}
+func (trs *TextRenderSystem) Update(engine *Engine, world *World) {
+	trs.text = engine.Text
+	for _, entity := range world.Query(COMPONENT_TRANSFORM|COMPONENT_TEXT) {
+		x, y := world.RenderPosition(entity, engine.Interpolation())
+		text := world.GetText(entity)
+		cacheId := textCacheId(entity)
+		engine.Text.Write(cacheId, text.Value)
+		texture := engine.Text.GetTexture(cacheId)
+		if texture != nil {
+			engine.Graphics.DrawFull(texture, int32(x), int32(y))
 		}
 	}
 }
 
 type HudTextSystem struct {
-	SystemEvents
+	sub *Subscription
 }
-func (chs *HudTextSystem) Init(world *World) {
-	world.Events.Subscribe("collection", chs)
+func (chs *HudTextSystem) Schedule() SystemConfig {
+	return SystemConfig{
+		Name: "hud-text",
+		Phase: PHASE_POST_SIMULATION,
+		After: []string{"entity-collection"},
+	}
 }
-func (chs *HudTextSystem) Update(engine *Engine, world *World) {
-	chs.SystemEvents.HandleEvents(func (event Event) {
-		evt, _ := event.(*CollectionEvent);
+func (chs *HudTextSystem) Init(world *World) {
+	chs.sub = On(world.Events, func(evt *CollectionEvent) {
+		// the hud entities are optional, headless runs don't create them
 		if evt.Collectible == "gold" {
-			text := world.GetTextByTag("player_coins")
-			text.Value = "Coins: " + strconv.Itoa(evt.Total)
+			if text := world.GetTextByTag("player_coins"); text != nil {
+				text.Value = "Coins: " + strconv.Itoa(evt.Total)
+			}
 		}
 		if evt.Collectible == "health" {
-			text := world.GetTextByTag("player_health")
-			text.Value = "X " + strconv.Itoa(evt.Total)
+			if text := world.GetTextByTag("player_health"); text != nil {
+				text.Value = "X " + strconv.Itoa(evt.Total)
+			}
 		}
 	})
 }
+func (chs *HudTextSystem) Update(engine *Engine, world *World) {}
 
+func textCacheId(entity Entity) string {
+	return strconv.FormatUint(uint64(entity), 10)
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"testing"
+)
+
+// newJumpTest builds a headless engine with the player standing on a
+// floor at y 144, with the body's max speed raised so jumps aren't clamped
+func newJumpTest(t *testing.T) (*Engine, *Transform, *PhysicsBody) {
+	t.Helper()
+	eng := newHeadlessEngine(t, EngineConfig{})
+	eng.Map = newTestMap([]string{
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"####################",
+	})
+	w := eng.World
+	player := w.entityBuilders["player"](w, 60, 130)
+	body := w.GetPhysicsBody(player)
+	body.MaxSpeedY = 10
+	eng.Step(10)
+	transform := w.GetTransform(player)
+	if !transform.Sensor.Bottom {
+		t.Fatal("player isn't on the floor")
+	}
+	return eng, transform, body
+}
+
+func pressJump(eng *Engine) {
+	eng.Input.SetKeyHeld(sdl.K_SPACE, true)
+	eng.Step(1)
+	eng.Input.SetKeyHeld(sdl.K_SPACE, false)
+}
+
+func TestJumpSpeed(t *testing.T) {
+	for _, jumpSpeed := range []float32{-5, -3} {
+		eng, transform, body := newJumpTest(t)
+		body.JumpSpeed = jumpSpeed
+		pressJump(eng)
+		if want := jumpSpeed + GRAVITY; transform.SpeedY != want {
+			t.Errorf("jump speed %v: speed %v after jumping, want %v", jumpSpeed, transform.SpeedY, want)
+		}
+	}
+}
+
+func TestDoubleJumpScale(t *testing.T) {
+	eng, transform, body := newJumpTest(t)
+	body.DoubleJumpScale = 0.5
+	pressJump(eng)
+	eng.Step(10)
+	before := transform.SpeedY
+	pressJump(eng)
+	if want := before + body.JumpSpeed*0.5 + GRAVITY; transform.SpeedY != want {
+		t.Errorf("speed %v after the double jump, want %v", transform.SpeedY, want)
+	}
+
+	// there's no third jump
+	eng.Step(10)
+	before = transform.SpeedY
+	pressJump(eng)
+	if want := before + GRAVITY; transform.SpeedY != want {
+		t.Errorf("speed %v after a third jump, want %v", transform.SpeedY, want)
+	}
+}
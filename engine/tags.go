@@ -0,0 +1,129 @@
+package engine
+
+// tagIndex maps tag and group names to the entities carrying them.  It is
+// kept up to date by AddTag/AddToGroup and friends, and by SetMask when
+// an entity gains or loses COMPONENT_TAG (prefabs, snapshots).  Editing a
+// Tag component's lists directly after the fact bypasses the index.
+type tagIndex struct {
+	tags   map[string][]Entity
+	groups map[string][]Entity
+}
+
+func newTagIndex() *tagIndex {
+	return &tagIndex{
+		tags:   make(map[string][]Entity),
+		groups: make(map[string][]Entity),
+	}
+}
+
+func (ti *tagIndex) add(entity Entity, tag *Tag) {
+	for _, value := range tag.Values {
+		ti.tags[value] = insertEntity(ti.tags[value], entity)
+	}
+	for _, group := range tag.Groups {
+		ti.groups[group] = insertEntity(ti.groups[group], entity)
+	}
+}
+
+func (ti *tagIndex) remove(entity Entity, tag *Tag) {
+	for _, value := range tag.Values {
+		ti.tags[value] = removeEntity(ti.tags[value], entity)
+	}
+	for _, group := range tag.Groups {
+		ti.groups[group] = removeEntity(ti.groups[group], entity)
+	}
+}
+
+// FindByTag returns the first entity carrying tag, or ENTITY_NONE
+func (w *World) FindByTag(tag string) Entity {
+	if entities := w.tags.tags[tag]; len(entities) > 0 {
+		return entities[0]
+	}
+	return ENTITY_NONE
+}
+
+// FindAllByTag returns every entity carrying tag.  Like Query the slice
+// must not be modified.
+func (w *World) FindAllByTag(tag string) []Entity {
+	return w.tags.tags[tag]
+}
+
+// FindAllInGroup returns every entity in group.  Like Query the slice
+// must not be modified.
+func (w *World) FindAllInGroup(group string) []Entity {
+	return w.tags.groups[group]
+}
+
+func (w *World) HasTag(entity Entity, tag string) bool {
+	return w.HasComponents(entity, COMPONENT_TAG) && containsString(w.GetTag(entity).Values, tag)
+}
+
+func (w *World) InGroup(entity Entity, group string) bool {
+	return w.HasComponents(entity, COMPONENT_TAG) && containsString(w.GetTag(entity).Groups, group)
+}
+
+// AddTag tags the entity, adding COMPONENT_TAG if needed
+func (w *World) AddTag(entity Entity, tag string) {
+	w.editTag(entity, func(t *Tag) {
+		if !containsString(t.Values, tag) {
+			t.Values = append(t.Values, tag)
+		}
+	})
+}
+
+func (w *World) RemoveTag(entity Entity, tag string) {
+	w.editTag(entity, func(t *Tag) {
+		t.Values = removeString(t.Values, tag)
+	})
+}
+
+// AddToGroup puts the entity in a named group, adding COMPONENT_TAG if needed
+func (w *World) AddToGroup(entity Entity, group string) {
+	w.editTag(entity, func(t *Tag) {
+		if !containsString(t.Groups, group) {
+			t.Groups = append(t.Groups, group)
+		}
+	})
+}
+
+func (w *World) RemoveFromGroup(entity Entity, group string) {
+	w.editTag(entity, func(t *Tag) {
+		t.Groups = removeString(t.Groups, group)
+	})
+}
+
+// editTag reindexes the entity around a change to its Tag component
+func (w *World) editTag(entity Entity, edit func(t *Tag)) {
+	if !w.Alive(entity) {
+		return
+	}
+	tag := w.GetTag(entity)
+	if !w.HasComponents(entity, COMPONENT_TAG) {
+		*tag = Tag{}
+		edit(tag)
+		w.AddComponents(entity, COMPONENT_TAG)
+		return
+	}
+	w.tags.remove(entity, tag)
+	edit(tag)
+	w.tags.add(entity, tag)
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, value string) []string {
+	var result []string
+	for _, v := range list {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
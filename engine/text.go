@@ -12,13 +12,23 @@ type TextNode struct {
 	texture *sdl.Texture
 }
 
-type TextManager struct {
+type TextManager interface {
+	Init()
+	Write(name string, text string)
+	GetTexture(name string) *sdl.Texture
+	Remove(name string)
+	Cleanup()
+}
+
+// SdlTextManager renders text with SDL_ttf and caches a texture per name
+type SdlTextManager struct {
 	engine *Engine
+	graphics *SdlGraphics
 	font *ttf.Font
 	cache map[string]*TextNode
 }
 
-func (tm *TextManager) Init() {
+func (tm *SdlTextManager) Init() {
 	if err := ttf.Init(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to init ttf: %s\n", err)
 	}
@@ -33,7 +43,7 @@ func (tm *TextManager) Init() {
 	tm.cache = make(map[string]*TextNode)
 }
 
-func (tm *TextManager) Write(name string, text string) {
+func (tm *SdlTextManager) Write(name string, text string) {
 	var err error
 	if node, ok := tm.cache[name]; ok {
 		if node.value != text {
@@ -54,14 +64,24 @@ func (tm *TextManager) Write(name string, text string) {
 	}
 }
 
-func (tm *TextManager) GetTexture(name string) *sdl.Texture {
+func (tm *SdlTextManager) GetTexture(name string) *sdl.Texture {
 	if node, ok := tm.cache[name]; ok {
 		return node.texture
 	}
 	return nil
 }
 
-func (tm *TextManager) createText(text string) (*sdl.Texture, error) {
+// Remove frees the texture cached under name
+func (tm *SdlTextManager) Remove(name string) {
+	if node, ok := tm.cache[name]; ok {
+		if node.texture != nil {
+			node.texture.Destroy()
+		}
+		delete(tm.cache, name)
+	}
+}
+
+func (tm *SdlTextManager) createText(text string) (*sdl.Texture, error) {
 	var solid *sdl.Surface
 	var err error
 	var texture *sdl.Texture
@@ -71,7 +91,7 @@ func (tm *TextManager) createText(text string) (*sdl.Texture, error) {
 		fmt.Fprintf(os.Stderr, "Failed to write string: %s", text)
 		return nil, err
 	} else {
-		texture, err = tm.engine.renderer.CreateTextureFromSurface(solid)
+		texture, err = tm.graphics.renderer.CreateTextureFromSurface(solid)
 		defer solid.Free()
 		if err != nil {
 			fmt.Println("error converting text surface to texture")
@@ -80,7 +100,7 @@ func (tm *TextManager) createText(text string) (*sdl.Texture, error) {
 	return texture, nil
 }
 
-func (tm *TextManager) Cleanup() {
+func (tm *SdlTextManager) Cleanup() {
 	for _, v := range tm.cache {
 		v.texture.Destroy()
 	}
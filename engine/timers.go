@@ -0,0 +1,117 @@
+package engine
+
+import "sort"
+
+// Timer is a callback scheduled on a Timers clock
+type Timer struct {
+	due uint32
+	// 0 for one-shot timers
+	interval uint32
+	fn       func()
+	// scheduling order, breaks ties between timers due at the same time
+	id     int
+	active bool
+}
+
+// Cancel stops the timer firing again.  It is safe to call on a timer
+// that has already fired or from inside its own callback.
+func (t *Timer) Cancel() {
+	if t != nil {
+		t.active = false
+	}
+}
+
+// Active reports whether the timer will still fire
+func (t *Timer) Active() bool {
+	return t != nil && t.active
+}
+
+// Timers runs callbacks and emits events after a delay or at an interval.
+// Times are milliseconds of game time, the engine advances the clock at
+// the start of every simulation tick so timers pause with the game.
+type Timers struct {
+	now    uint32
+	events *Dispatcher
+	// pending timers sorted by due time
+	timers []*Timer
+	nextId int
+}
+
+func NewTimers(events *Dispatcher) *Timers {
+	return &Timers{events: events}
+}
+
+// Now is the game time the clock was last advanced to
+func (ts *Timers) Now() uint32 {
+	return ts.now
+}
+
+// After calls fn once, delay milliseconds from now
+func (ts *Timers) After(delay uint32, fn func()) *Timer {
+	return ts.schedule(delay, 0, fn)
+}
+
+// Every calls fn every interval milliseconds, starting interval from now,
+// until the timer is cancelled
+func (ts *Timers) Every(interval uint32, fn func()) *Timer {
+	// a zero interval would fire forever within a single advance
+	if interval == 0 {
+		interval = 1
+	}
+	return ts.schedule(interval, interval, fn)
+}
+
+// EmitAfter emits event once, delay milliseconds from now
+func (ts *Timers) EmitAfter(delay uint32, event Event) *Timer {
+	return ts.After(delay, func() { ts.events.EmitEvent(event) })
+}
+
+// EmitEvery emits event every interval milliseconds.  The same event
+// value is sent each time.
+func (ts *Timers) EmitEvery(interval uint32, event Event) *Timer {
+	return ts.Every(interval, func() { ts.events.EmitEvent(event) })
+}
+
+func (ts *Timers) schedule(delay, interval uint32, fn func()) *Timer {
+	t := &Timer{
+		due:      ts.now + delay,
+		interval: interval,
+		fn:       fn,
+		id:       ts.nextId,
+		active:   true,
+	}
+	ts.nextId++
+	ts.insert(t)
+	return t
+}
+
+func (ts *Timers) insert(t *Timer) {
+	pos := sort.Search(len(ts.timers), func(i int) bool {
+		other := ts.timers[i]
+		return other.due > t.due || (other.due == t.due && other.id > t.id)
+	})
+	ts.timers = append(ts.timers, nil)
+	copy(ts.timers[pos+1:], ts.timers[pos:])
+	ts.timers[pos] = t
+}
+
+// Advance moves the clock to now and fires every timer that has come due,
+// in due order.  A repeating timer fires once for each interval that has
+// passed.
+func (ts *Timers) Advance(now uint32) {
+	ts.now = now
+	for len(ts.timers) > 0 && ts.timers[0].due <= now {
+		t := ts.timers[0]
+		ts.timers = ts.timers[1:]
+		if !t.active {
+			continue
+		}
+		if t.interval > 0 {
+			t.due += t.interval
+			ts.insert(t)
+		} else {
+			t.active = false
+		}
+		t.fn()
+	}
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTimersAfterAndEvery(t *testing.T) {
+	ts := NewTimers(NewDispatcher())
+	var fired []string
+	once := ts.After(50, func() { fired = append(fired, "after") })
+	ts.Every(20, func() { fired = append(fired, "every") })
+
+	for now := uint32(0); now <= 60; now += 10 {
+		ts.Advance(now)
+	}
+	want := []string{"every", "every", "after", "every"}
+	if !reflect.DeepEqual(fired, want) {
+		t.Fatalf("got %v, want %v", fired, want)
+	}
+	if once.Active() {
+		t.Error("one-shot timer still active after firing")
+	}
+}
+
+func TestTimersCatchUp(t *testing.T) {
+	ts := NewTimers(NewDispatcher())
+	var times []uint32
+	ts.Every(20, func() { times = append(times, ts.Now()) })
+	fired := 0
+	ts.After(30, func() { fired++ })
+
+	// one long tick covers several intervals
+	ts.Advance(100)
+	if len(times) != 5 || fired != 1 {
+		t.Fatalf("repeating fired %d times, one-shot %d, want 5 and 1", len(times), fired)
+	}
+	ts.Advance(119)
+	ts.Advance(120)
+	if len(times) != 6 {
+		t.Fatalf("repeating fired %d times by 120, want 6", len(times))
+	}
+}
+
+func TestTimersCancelInsideCallback(t *testing.T) {
+	ts := NewTimers(NewDispatcher())
+	count := 0
+	var repeating *Timer
+	repeating = ts.Every(10, func() {
+		count++
+		if count == 3 {
+			repeating.Cancel()
+		}
+	})
+	var later *Timer
+	laterFired := false
+	ts.After(10, func() { later.Cancel() })
+	later = ts.After(15, func() { laterFired = true })
+
+	ts.Advance(100)
+	if count != 3 || repeating.Active() {
+		t.Fatalf("cancelled timer fired %d times, active %v", count, repeating.Active())
+	}
+	if laterFired || later.Active() {
+		t.Fatal("timer cancelled by another timer's callback still fired")
+	}
+}
+
+func TestTimersEmit(t *testing.T) {
+	d := NewDispatcher()
+	ts := NewTimers(d)
+	clips := 0
+	On(d, func(*AudioEvent) { clips++ })
+	ts.EmitAfter(10, &AudioEvent{Clip: "jump.wav"})
+	repeat := ts.EmitEvery(10, &AudioEvent{Clip: "tick.wav"})
+
+	ts.Advance(30)
+	d.Flush()
+	repeat.Cancel()
+	ts.Advance(60)
+	d.Flush()
+	if clips != 4 {
+		t.Fatalf("got %d events, want 4", clips)
+	}
+}
+
+func TestTimersStopWhilePaused(t *testing.T) {
+	eng, _ := newHeadlessLevel(t, EngineConfig{})
+	fired := 0
+	eng.World.Timers.Every(100, func() { fired++ })
+
+	// timers are advanced at the start of each tick, so the 31st tick
+	// is the first to see 500ms
+	eng.Step(31)
+	if fired != 5 {
+		t.Fatalf("fired %d times in 500ms, want 5", fired)
+	}
+	eng.SetPaused(true)
+	eng.Step(60)
+	if fired != 5 {
+		t.Fatalf("fired while paused, %d times", fired)
+	}
+	eng.SetPaused(false)
+	eng.Step(6)
+	if fired != 6 {
+		t.Fatalf("fired %d times after unpausing, want 6", fired)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"errors"
+	"path/filepath"
 )
 
 type TmxMap struct {
@@ -34,10 +35,37 @@ type TmxProperty struct {
 
 type TmxTileset struct {
 	FirstGid   int        `xml:"firstgid,attr"`
+	// external .tsx file the rest of the tileset is loaded from
+	Source     string     `xml:"source,attr"`
 	Name       string     `xml:"name,attr"`
 	TileWidth  int        `xml:"tilewidth,attr"`
 	TileHeight int        `xml:"tileheight,attr"`
+	TileCount  int        `xml:"tilecount,attr"`
 	Images     []TmxImage `xml:"image"`
+	Tiles      []TmxTile  `xml:"tile"`
+}
+
+// TmxTile holds the per tile data set in the tileset editor
+type TmxTile struct {
+	Id         int             `xml:"id,attr"`
+	Properties []TmxProperties `xml:"properties"`
+}
+
+// TileProperty returns a property set on the tile with the given local id
+func (ts *TmxTileset) TileProperty(id int, name string) (string, bool) {
+	for _, tile := range ts.Tiles {
+		if tile.Id != id {
+			continue
+		}
+		for _, props := range tile.Properties {
+			for _, prop := range props.Property {
+				if prop.Name == name {
+					return prop.Value, true
+				}
+			}
+		}
+	}
+	return "", false
 }
 
 type TmxImage struct {
@@ -67,11 +95,78 @@ type TmxObjectGroup struct {
 }
 
 type TmxObject struct {
-	Type   string `xml:"type,attr"`
-	X      int    `xml:"x,attr"`
-	Y      int    `xml:"y,attr"`
-	Width  int    `xml:"width,attr"`
-	Height int    `xml:"height,attr"`
+	Id         int             `xml:"id,attr"`
+	Type       string          `xml:"type,attr"`
+	// set on tile objects, whose x, y is their bottom left corner
+	Gid        int             `xml:"gid,attr"`
+	X          int             `xml:"x,attr"`
+	Y          int             `xml:"y,attr"`
+	Width      int             `xml:"width,attr"`
+	Height     int             `xml:"height,attr"`
+	Properties []TmxProperties `xml:"properties"`
+	Polyline   *TmxPolyline    `xml:"polyline"`
+}
+
+// TmxPolyline points are relative to the object's x, y, e.g. "0,0 64,0 64,-32"
+type TmxPolyline struct {
+	Points string `xml:"points,attr"`
+}
+
+// Property returns a custom property set on the object
+func (o *TmxObject) Property(name string) (string, bool) {
+	for _, props := range o.Properties {
+		for _, prop := range props.Property {
+			if prop.Name == name {
+				return prop.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Center is the middle of the object in map pixels
+func (o *TmxObject) Center() (float32, float32) {
+	x := float32(o.X) + float32(o.Width) / 2
+	if o.Gid != 0 {
+		return x, float32(o.Y) - float32(o.Height) / 2
+	}
+	return x, float32(o.Y) + float32(o.Height) / 2
+}
+
+// PolylinePoints returns the object's polyline in map pixels
+func (o *TmxObject) PolylinePoints() ([]PathPoint, error) {
+	if o.Polyline == nil {
+		return nil, nil
+	}
+	var points []PathPoint
+	for _, pair := range strings.Fields(o.Polyline.Points) {
+		xy := strings.Split(pair, ",")
+		if len(xy) != 2 {
+			return nil, fmt.Errorf("bad polyline point: %s", pair)
+		}
+		x, err := strconv.ParseFloat(xy[0], 32)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(xy[1], 32)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, PathPoint{X: float32(o.X) + float32(x), Y: float32(o.Y) + float32(y)})
+	}
+	return points, nil
+}
+
+// GetObjectById returns the object with the given id from any object group
+func (t *TmxMap) GetObjectById(id int) *TmxObject {
+	for i := range t.ObjectGroups {
+		for j := range t.ObjectGroups[i].Objects {
+			if obj := &t.ObjectGroups[i].Objects[j]; obj.Id == id {
+				return obj
+			}
+		}
+	}
+	return nil
 }
 
 func (t *TmxMap) GetLayerByName(name string) (*TmxLayer, error) {
@@ -88,6 +183,22 @@ func (t *TmxMap) GetLayerByName(name string) (*TmxLayer, error) {
 	return layer, err
 }
 
+// GetTilesetByGid returns the tileset a global tile id belongs to and the
+// tile's id within it
+func (t *TmxMap) GetTilesetByGid(gid int) (*TmxTileset, int) {
+	var found *TmxTileset
+	for i := range t.Tilesets {
+		ts := &t.Tilesets[i]
+		if ts.FirstGid <= gid && (found == nil || ts.FirstGid > found.FirstGid) {
+			found = ts
+		}
+	}
+	if found == nil {
+		return nil, 0
+	}
+	return found, gid - found.FirstGid
+}
+
 func (t *TmxMap) GetObjGroupByName(name string) (*TmxObjectGroup, error) {
 	var group *TmxObjectGroup
 	var err error
@@ -131,5 +242,34 @@ func NewTmxMap(path string) (TmxMap, error) {
 	}
 
 	parsed, err := parseTmxMap(f)
-	return parsed, err
+	if err != nil {
+		return parsed, err
+	}
+	for i := range parsed.Tilesets {
+		if err := loadTmxTileset(&parsed.Tilesets[i], filepath.Dir(path)); err != nil {
+			return parsed, err
+		}
+	}
+	return parsed, nil
+}
+
+// loadTmxTileset fills in a tileset that refers to an external .tsx file,
+// the path is relative to the map
+func loadTmxTileset(ts *TmxTileset, dir string) error {
+	if ts.Source == "" {
+		return nil
+	}
+	path := filepath.Join(dir, ts.Source)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// firstgid and source only exist in the map
+	firstGid, source := ts.FirstGid, ts.Source
+	if err := xml.Unmarshal(b, ts); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	ts.FirstGid, ts.Source = firstGid, source
+	return nil
 }
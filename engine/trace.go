@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"reflect"
+)
+
+// TraceEntry is one line of an event trace file
+type TraceEntry struct {
+	// simulation tick and game time in milliseconds the event was emitted at
+	Frame uint64 `json:"frame"`
+	Time  uint32 `json:"time"`
+	Type  string `json:"type"`
+	Async bool   `json:"async,omitempty"`
+	// slot indexes of every Entity field in the event, so tools can
+	// filter on them without knowing each event's layout
+	Entities []int           `json:"entities,omitempty"`
+	Event    json.RawMessage `json:"event"`
+}
+
+// EventTrace writes every event emitted through a Dispatcher to a file,
+// one json TraceEntry per line.  cmd/eventtrace filters and summarizes
+// the result.
+type EventTrace struct {
+	file   *os.File
+	writer *bufio.Writer
+	// frame and game time entries are stamped with
+	clock func() (uint64, uint32)
+}
+
+func NewEventTrace(path string, clock func() (uint64, uint32)) (*EventTrace, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &EventTrace{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		clock:  clock,
+	}, nil
+}
+
+func (t *EventTrace) Record(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	frame, time := t.clock()
+	line, err := json.Marshal(TraceEntry{
+		Frame:    frame,
+		Time:     time,
+		Type:     e.Type(),
+		Async:    e.Async(),
+		Entities: eventEntities(e),
+		Event:    payload,
+	})
+	if err != nil {
+		return err
+	}
+	t.writer.Write(line)
+	return t.writer.WriteByte('\n')
+}
+
+func (t *EventTrace) Close() error {
+	if err := t.writer.Flush(); err != nil {
+		t.file.Close()
+		return err
+	}
+	return t.file.Close()
+}
+
+var entityType = reflect.TypeOf(ENTITY_NONE)
+
+// eventEntities returns the slot index of every Entity field in the event
+func eventEntities(e Event) []int {
+	v := reflect.Indirect(reflect.ValueOf(e))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var indexes []int
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Field(i); field.Type() == entityType {
+			indexes = append(indexes, Entity(field.Uint()).Index())
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"testing"
+)
+
+// newWaterTest builds a headless engine around a map with a floor at
+// y 144 and water from y 64 down to it, and drops the player in from above
+func newWaterTest(t *testing.T) (*Engine, Entity, *Water) {
+	t.Helper()
+	eng := newHeadlessEngine(t, EngineConfig{})
+	eng.Map = newTestMap([]string{
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"....................",
+		"####################",
+	})
+
+	w := eng.World
+	water := w.entityBuilders["water"](w, 0, 64)
+	transform := w.GetTransform(water)
+	transform.W, transform.H = 320, 80
+	player := w.entityBuilders["player"](w, 60, 20)
+	return eng, player, w.GetWater(water)
+}
+
+// middle is the point water is checked at
+func middle(transform *Transform) float32 {
+	return transform.Y + float32(transform.H)/2
+}
+
+func TestWaterEnterAndExit(t *testing.T) {
+	eng, player, _ := newWaterTest(t)
+	transform := eng.World.GetTransform(player)
+	var entered, exited []float32
+	On(eng.World.Events, func(e *WaterEnterEvent) { entered = append(entered, middle(transform)) })
+	On(eng.World.Events, func(e *WaterExitEvent) { exited = append(exited, middle(transform)) })
+
+	// fall in
+	var before float32
+	for i := 0; i < 120 && len(entered) == 0; i++ {
+		before = middle(transform)
+		eng.Step(1)
+	}
+	if len(entered) != 1 {
+		t.Fatalf("entered the water %d times falling in", len(entered))
+	}
+	if before >= 64 || entered[0] < 64 {
+		t.Errorf("entered with the middle moving from %v to %v, want crossing 64", before, entered[0])
+	}
+	eng.Step(60)
+	if transform.Sensor.Water == ENTITY_NONE || len(exited) != 0 {
+		t.Fatal("left the water sinking to the bottom")
+	}
+
+	// swim up and out
+	for i := 0; i < 200 && len(exited) == 0; i++ {
+		eng.Input.SetKeyHeld(sdl.K_SPACE, i%2 == 0)
+		before = middle(transform)
+		eng.Step(1)
+	}
+	if len(exited) != 1 {
+		t.Fatalf("left the water %d times swimming up", len(exited))
+	}
+	if before < 64 || exited[0] >= 64 {
+		t.Errorf("left with the middle moving from %v to %v, want crossing 64", before, exited[0])
+	}
+	if len(entered) != 1 || transform.Sensor.Water != ENTITY_NONE {
+		t.Errorf("entered %d times, in water %v", len(entered), transform.Sensor.Water)
+	}
+}
+
+func TestWaterScalesGravityAndMaxSpeed(t *testing.T) {
+	eng, player, water := newWaterTest(t)
+	transform := eng.World.GetTransform(player)
+	body := eng.World.GetPhysicsBody(player)
+	// well under the surface
+	eng.Step(60)
+	if transform.Sensor.Water == ENTITY_NONE {
+		t.Fatal("player didn't fall into the water")
+	}
+
+	physics := &PhysicsSystem{}
+	transform.SpeedX, transform.SpeedY = 0, 0
+	physics.Update(eng, eng.World)
+	if want := GRAVITY * body.GravityScale * water.Gravity; transform.AccelY != want || transform.SpeedY != want {
+		t.Errorf("accel %v speed %v, want gravity scaled to %v", transform.AccelY, transform.SpeedY, want)
+	}
+
+	transform.SpeedX, transform.SpeedY = 10, 10
+	physics.Update(eng, eng.World)
+	if transform.SpeedX != body.MaxSpeedX*water.MaxSpeed || transform.SpeedY != body.MaxSpeedY*water.MaxSpeed {
+		t.Errorf("speed %v,%v, want max speeds scaled to %v,%v", transform.SpeedX, transform.SpeedY,
+			body.MaxSpeedX*water.MaxSpeed, body.MaxSpeedY*water.MaxSpeed)
+	}
+}
+
+func TestWaterStroke(t *testing.T) {
+	eng, player, water := newWaterTest(t)
+	// no max speed so the stroke isn't clamped
+	water.MaxSpeed = 10
+	transform := eng.World.GetTransform(player)
+	body := eng.World.GetPhysicsBody(player)
+	eng.Step(40)
+	if transform.Sensor.Water == ENTITY_NONE {
+		t.Fatal("player didn't fall into the water")
+	}
+
+	want := body.JumpSpeed*water.Jump + GRAVITY*body.GravityScale*water.Gravity
+	// every stroke sets the same speed whatever the player was moving at,
+	// and there's no limit on them like there is on jumps
+	for i, sinking := range []float32{0, 0.5, 1.5, -1, 2} {
+		transform.SpeedY = sinking
+		eng.Input.SetKeyHeld(sdl.K_SPACE, true)
+		eng.Step(1)
+		if transform.SpeedY != want {
+			t.Errorf("stroke %d from speed %v: speed %v, want %v", i+1, sinking, transform.SpeedY, want)
+		}
+		eng.Input.SetKeyHeld(sdl.K_SPACE, false)
+		eng.Step(1)
+		if transform.Sensor.Water == ENTITY_NONE {
+			t.Fatalf("swam out of the water after %d strokes", i+1)
+		}
+	}
+}
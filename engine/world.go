@@ -6,44 +6,82 @@ import (
 	"os"
 )
 
-const ENTITY_COUNT = 100
+// initial number of entity slots, storage grows past this on demand
+const ENTITY_CAPACITY = 100
 
-type EntityBuilder func(world *World, x, y float32) int
+type EntityBuilder func(world *World, x, y float32) Entity
 
 type World struct {
-	// data components
-	Mask        	[ENTITY_COUNT]uint64
-	Transform   	[ENTITY_COUNT]Transform
-	Animation   	[ENTITY_COUNT]Animation
-	State       	[ENTITY_COUNT]State
-	Tag		    	[ENTITY_COUNT]Tag
-	Collectible 	[ENTITY_COUNT]Collectible
-	Inventory   	[ENTITY_COUNT]Inventory
-	Text        	[ENTITY_COUNT]Text
-
-	// no data components
-	Focused     	[ENTITY_COUNT]Focused
-	Controller  	[ENTITY_COUNT]Controller
-	Collidable  	[ENTITY_COUNT]Collidable
-	Hud         	[ENTITY_COUNT]Hud
-
-	systems []System
+	mask        []uint64
+
+	// component storage indexed by registered component id
+	stores      []componentStore
+
+	// entity slot bookkeeping
+	generations []uint32
+	alive       []bool
+	freeList    []int
+	// slots destroyed this frame, not reused until the frame ends
+	pendingFree []int
+
+	// cached Query results keyed by signature
+	queries map[uint64]*query
+	// tag and group lookups, see tags.go
+	tags *tagIndex
+
+	// registered systems in run order, see scheduler.go
+	systems []*scheduledSystem
+	disabledPhases [PHASE_COUNT]bool
 	entityBuilders map[string]EntityBuilder
 	Events *Dispatcher
+	Commands *CommandBuffer
+	// delayed and repeating callbacks on the game clock, see timers.go
+	Timers *Timers
 }
 
 func NewWorld() *World {
-	return &World {
-		Events: NewDispatcher(),
+	events := NewDispatcher()
+	w := &World {
+		Events: events,
+		Commands: NewCommandBuffer(),
+		Timers: NewTimers(events),
+		queries: make(map[uint64]*query),
+		tags: newTagIndex(),
+	}
+	w.grow(ENTITY_CAPACITY)
+	return w
+}
+
+// grow appends n empty slots to every component store
+func (w *World) grow(n int) {
+	start := len(w.mask)
+	w.mask = append(w.mask, make([]uint64, n)...)
+	for _, s := range w.stores {
+		if s != nil {
+			s.grow(n)
+		}
+	}
+	w.generations = append(w.generations, make([]uint32, n)...)
+	w.alive = append(w.alive, make([]bool, n)...)
+
+	// hand out low indices first
+	for index := start + n - 1; index >= start; index-- {
+		w.freeList = append(w.freeList, index)
 	}
 }
 
-func (w *World) RegisterSystem(system System) {
-	if w.systems == nil {
-		w.systems = make([]System, 0)
+// store returns the world's storage for a registered component,
+// creating it on first use so components can be registered after
+// the world exists
+func (w *World) store(id int) componentStore {
+	for len(w.stores) <= id {
+		w.stores = append(w.stores, nil)
 	}
-	system.Init(w)
-	w.systems = append(w.systems, system)
+	if w.stores[id] == nil {
+		w.stores[id] = components[id].newStore()
+		w.stores[id].grow(len(w.mask))
+	}
+	return w.stores[id]
 }
 
 func (w *World) RegisterEntityBuilder(name string, builder EntityBuilder) {
@@ -53,84 +91,249 @@ func (w *World) RegisterEntityBuilder(name string, builder EntityBuilder) {
 	w.entityBuilders[name] = builder
 }
 
+// Update runs every phase once
 func (w *World) Update(engine *Engine) {
+	w.UpdatePhases(engine, PHASE_INPUT, PHASE_HUD)
+	w.FlushEvents()
+}
+
+// UpdatePhases runs the enabled systems in phases first through last
+func (w *World) UpdatePhases(engine *Engine, first, last SystemPhase) {
+	// apply anything queued outside of the systems (map loading, etc)
+	w.Commands.Flush(w)
 	for _, system := range w.systems {
+		phase := system.config.Phase
+		if phase < first || phase > last || !system.enabled || w.disabledPhases[phase] {
+			continue
+		}
 		system.Update(engine, w)
+		// sync point, structural changes queued by this system
+		// are visible to every system after it
+		w.Commands.Flush(w)
 	}
+
+	// slots freed during the frame can be reused from now on
+	w.freeList = append(w.freeList, w.pendingFree...)
+	w.pendingFree = w.pendingFree[:0]
 }
 
-func (w *World) GetMask(entity int) *uint64 {
-	return &w.Mask[entity]
+// FlushEvents is the end of frame sync point.  It delivers the async
+// events emitted during the frame, along with any they trigger, then
+// applies the commands their handlers queued.
+func (w *World) FlushEvents() {
+	w.Events.Flush()
+	w.Commands.Flush(w)
 }
 
-func (w *World) GetTransform(entity int) *Transform {
-	return &w.Transform[entity]
+// StorePreviousTransforms records where moving entities are before a
+// simulation tick so rendering can interpolate towards the new position
+func (w *World) StorePreviousTransforms() {
+	for _, entity := range w.Query(COMPONENT_TRANSFORM|COMPONENT_VELOCITY) {
+		transform := w.GetTransform(entity)
+		transform.PrevX = transform.X
+		transform.PrevY = transform.Y
+	}
 }
 
-func (w *World) GetAnimation(entity int) *Animation {
-	return &w.Animation[entity]
+// GetMask returns the entity's component mask, or COMPONENT_NONE if
+// the entity is dead
+func (w *World) GetMask(entity Entity) uint64 {
+	if !w.Alive(entity) {
+		return COMPONENT_NONE
+	}
+	return w.mask[entity.Index()]
 }
 
-func (w *World) GetState(entity int) *State {
-	return &w.State[entity]
+// SetMask replaces the entity's component mask.  All mask changes go
+// through here so cached queries stay in sync.
+func (w *World) SetMask(entity Entity, mask uint64) {
+	if !w.Alive(entity) {
+		fmt.Fprintf(os.Stderr, "SetMask called on dead entity: %d\n", entity.Index())
+		return
+	}
+	index := entity.Index()
+	old := w.mask[index]
+	w.mask[index] = mask
+	for _, q := range w.queries {
+		q.update(entity, old, mask)
+	}
+
+	hadTag := signatureMatches(old, COMPONENT_TAG)
+	hasTag := signatureMatches(mask, COMPONENT_TAG)
+	if hasTag && !hadTag {
+		w.tags.add(entity, w.GetTag(entity))
+	} else if hadTag && !hasTag {
+		w.tags.remove(entity, w.GetTag(entity))
+	}
+
+	w.emitComponentChanges(entity, old, mask)
 }
 
-func (w *World) GetTag(entity int) *Tag {
-	return &w.Tag[entity]
+// emitComponentChanges emits an added or removed event for every bit
+// that differs between old and mask
+func (w *World) emitComponentChanges(entity Entity, old, mask uint64) {
+	for changed := old ^ mask; changed != 0; changed &= changed - 1 {
+		bit := changed & -changed
+		if mask&bit != 0 {
+			w.Events.EmitEvent(&ComponentAddedEvent{Entity: entity, Component: bit})
+		} else {
+			w.Events.EmitEvent(&ComponentRemovedEvent{Entity: entity, Component: bit})
+		}
+	}
 }
 
-func (w *World) GetCollectible(entity int) *Collectible {
-	return &w.Collectible[entity]
+func (w *World) AddComponents(entity Entity, signature uint64) {
+	w.SetMask(entity, w.GetMask(entity)|signature)
 }
 
-func (w *World) GetInventory(entity int) *Inventory {
-	return &w.Inventory[entity]
+func (w *World) RemoveComponents(entity Entity, signature uint64) {
+	w.SetMask(entity, w.GetMask(entity)&^signature)
 }
 
-func (w *World) GetText(entity int) *Text {
-	return &w.Text[entity]
+func (w *World) HasComponents(entity Entity, signature uint64) bool {
+	return w.Alive(entity) && signatureMatches(w.GetMask(entity), signature)
+}
+
+func (w *World) GetTransform(entity Entity) *Transform {
+	return TransformComponent.Get(w, entity)
+}
+
+func (w *World) GetAnimation(entity Entity) *Animation {
+	return AnimationComponent.Get(w, entity)
+}
+
+func (w *World) GetState(entity Entity) *State {
+	return StateComponent.Get(w, entity)
+}
+
+func (w *World) GetTag(entity Entity) *Tag {
+	return TagComponent.Get(w, entity)
+}
+
+func (w *World) GetCollectible(entity Entity) *Collectible {
+	return CollectibleComponent.Get(w, entity)
+}
+
+func (w *World) GetInventory(entity Entity) *Inventory {
+	return InventoryComponent.Get(w, entity)
+}
+
+func (w *World) GetText(entity Entity) *Text {
+	return TextComponent.Get(w, entity)
 }
 
 func (w *World) GetTextByTag(value string) *Text {
-	for entity, mask := range w.Mask {
-		if signatureMatches(mask, COMPONENT_TAG|COMPONENT_TEXT) {
-			tag := w.GetTag(entity)
-			if tag.Value == value {
-				return w.GetText(entity)
-			}
+	for _, entity := range w.FindAllByTag(value) {
+		if w.HasComponents(entity, COMPONENT_TEXT) {
+			return w.GetText(entity)
 		}
 	}
 	return nil
 }
 
-func (w *World) GetHud(entity int) *Hud {
-	return &w.Hud[entity]
+func (w *World) GetHud(entity Entity) *Hud {
+	return HudComponent.Get(w, entity)
 }
 
-func (w *World) CreateEntity() int {
-	for entity := 0; entity < ENTITY_COUNT; entity++ {
-		if w.Mask[entity] == COMPONENT_NONE {
-			return entity
+func (w *World) GetPlatform(entity Entity) *Platform {
+	return PlatformComponent.Get(w, entity)
+}
+
+func (w *World) GetWater(entity Entity) *Water {
+	return WaterComponent.Get(w, entity)
+}
+
+// GetPhysicsBody returns the entity's physics body, or the defaults for
+// entities without one
+func (w *World) GetPhysicsBody(entity Entity) *PhysicsBody {
+	if !PhysicsBodyComponent.Has(w, entity) {
+		body := DefaultPhysicsBody
+		return &body
+	}
+	return PhysicsBodyComponent.Get(w, entity)
+}
+
+// clear zeroes every component in a slot so a reused slot
+// doesn't inherit data from the entity that last lived there
+func (w *World) clear(index int) {
+	w.mask[index] = COMPONENT_NONE
+	for _, s := range w.stores {
+		if s != nil {
+			s.clear(index)
 		}
 	}
+}
+
+// Alive reports whether the handle still refers to a live entity.  Handles
+// to destroyed entities stay dead even after their slot has been reused.
+func (w *World) Alive(entity Entity) bool {
+	index := entity.Index()
+	if index >= len(w.alive) {
+		return false
+	}
+	return w.alive[index] && w.generations[index] == entity.Generation()
+}
 
-	fmt.Fprintf(os.Stderr, "No more entities left\n")
-	return ENTITY_COUNT
+// EntityAt returns the handle for the live entity stored at a slot index,
+// or ENTITY_NONE if the slot is free.
+func (w *World) EntityAt(index int) Entity {
+	if index < 0 || index >= len(w.alive) || !w.alive[index] {
+		return ENTITY_NONE
+	}
+	return NewEntity(index, w.generations[index])
 }
 
-func (w *World) DestroyEntity(entity int) {
+func (w *World) CreateEntity() Entity {
+	if len(w.freeList) == 0 {
+		w.grow(len(w.mask))
+	}
+
+	index := w.freeList[len(w.freeList)-1]
+	w.freeList = w.freeList[:len(w.freeList)-1]
+
+	w.generations[index]++
+	w.alive[index] = true
+	w.clear(index)
+	entity := NewEntity(index, w.generations[index])
+	w.Events.EmitEvent(&EntityCreatedEvent{Entity: entity})
+	return entity
+}
+
+// DestroyEntity removes the entity immediately.  Systems should prefer
+// Commands.DestroyEntity so the entity stays intact until the next sync
+// point.  Children are destroyed along with their parent.  The slot
+// isn't reused until the current frame finishes.
+func (w *World) DestroyEntity(entity Entity) {
+	if !w.Alive(entity) {
+		fmt.Fprintf(os.Stderr, "Attempted to destroy dead entity: %d\n", entity.Index())
+		return
+	}
 	// fmt.Fprintf(os.Stdout, "Entity destroyed: %d\n", entity)
-	w.Mask[entity] = COMPONENT_NONE
+	for _, child := range w.GetChildren(entity) {
+		w.DestroyEntity(child)
+	}
+	mask := w.GetMask(entity)
+	w.SetMask(entity, COMPONENT_NONE)
+	index := entity.Index()
+	w.alive[index] = false
+	w.pendingFree = append(w.pendingFree, index)
+	w.Events.EmitEvent(&EntityDestroyedEvent{Entity: entity, Mask: mask})
 }
 
-func (w *World) GetColliders() []int {
-	var list []int
-	for entity, signature := range w.Mask {
-		if signatureMatches(signature, COMPONENT_TRANSFORM) {
-			list = append(list, entity)
+// FindAt returns the first entity with all the components in signature
+// whose transform covers the point, or ENTITY_NONE
+func (w *World) FindAt(signature uint64, x, y float32) Entity {
+	for _, entity := range w.Query(signature|COMPONENT_TRANSFORM) {
+		t := w.GetTransform(entity)
+		if x >= t.X && x < t.X + float32(t.W) && y >= t.Y && y < t.Y + float32(t.H) {
+			return entity
 		}
 	}
-	return list
+	return ENTITY_NONE
+}
+
+func (w *World) GetColliders() []Entity {
+	return w.Query(COMPONENT_TRANSFORM|COMPONENT_COLLIDABLE)
 }
 
 func (w *World) Collides(a, b sdl.Rect) bool {
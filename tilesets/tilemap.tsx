@@ -1,4 +1,39 @@
 <?xml version="1.0" encoding="UTF-8"?>
-<tileset name="tilemap" tilewidth="16" tileheight="16" tilecount="35" columns="5">
- <image source="tilemap.png" width="80" height="112"/>
+<tileset name="tilemap" tilewidth="16" tileheight="16" tilecount="40" columns="5">
+ <image source="tilemap.png" width="80" height="128"/>
+ <tile id="23">
+  <properties>
+   <property name="type" value="slope_up"/>
+  </properties>
+ </tile>
+ <tile id="26">
+  <properties>
+   <property name="type" value="slope_up_low"/>
+  </properties>
+ </tile>
+ <tile id="27">
+  <properties>
+   <property name="type" value="slope_up_high"/>
+  </properties>
+ </tile>
+ <tile id="33">
+  <properties>
+   <property name="type" value="oneway"/>
+  </properties>
+ </tile>
+ <tile id="35">
+  <properties>
+   <property name="type" value="slope_down"/>
+  </properties>
+ </tile>
+ <tile id="36">
+  <properties>
+   <property name="type" value="slope_down_high"/>
+  </properties>
+ </tile>
+ <tile id="37">
+  <properties>
+   <property name="type" value="slope_down_low"/>
+  </properties>
+ </tile>
 </tileset>